@@ -11,6 +11,7 @@ import (
 	"os/signal"
 	"sway-icon-to-go/internal/cache"
 	"sway-icon-to-go/internal/config"
+	"sway-icon-to-go/internal/desktop"
 	"sway-icon-to-go/internal/display"
 	"sway-icon-to-go/internal/proc"
 	"sway-icon-to-go/internal/service"
@@ -32,8 +33,12 @@ func main() {
 	// Set up the flags
 	format := config.DefaultFormat()
 	flag.BoolVar(&format.Uniq, "u", format.Uniq, "display only unique icons (default true)")
+	flag.BoolVar(&format.Count, "n", format.Count, "display unique icons with the window count, e.g. terminal×3")
 	flag.IntVar(&format.Length, "l", format.Length, "trim app names to this length, -1 = no trim (default 12)")
+	flag.IntVar(&format.MaxIcons, "m", format.MaxIcons, "display at most this many icons, 0 = no limit (default 0)")
+	flag.StringVar(&format.Sort, "s", format.Sort, "icon order: tree, position, alpha, focus or priority (default tree)")
 	flag.StringVar(&format.Delimiter, "d", format.Delimiter, "app separator (default \"|\")")
+	flag.StringVar(&format.Template, "f", format.Template, "workspace name template, text/template syntax (default built from -d)")
 	flag.BoolVar(&verbose, "v", false, "enable verbose/debug logging")
 
 	// Set up the config path
@@ -104,7 +109,7 @@ func main() {
 		os.Exit(1)
 	}
 	// Run the application
-	run(appConfig, *appIconsConfigPath, faIconsConfigPath)
+	run(appConfig, format, *appIconsConfigPath, faIconsConfigPath)
 }
 
 func setupLogger(verbose bool) {
@@ -119,16 +124,32 @@ func setupLogger(verbose bool) {
 }
 
 // run runs the application.
-func run(appConfig *config.Config, appIconsConfigPath string, faIconsConfigPath string) {
-	nameFormatter := display.NewNameFormatter(appConfig.Format)
+// The format is the one of the command line, the config file format section is applied over it on every reload.
+func run(appConfig *config.Config, format *config.Format, appIconsConfigPath string, faIconsConfigPath string) {
+	nameFormatter, err := display.NewOutputNameFormatter(appConfig)
+	if err != nil {
+		slog.Error("Error while setting up the workspace name format", "error", err)
+		os.Exit(1)
+	}
 
 	// Set up the pid to name resolver
-	resolver := proc.LinuxResolver{ProcPath: procPath}
-	processManager := proc.NewProcessManager(&resolver)
+	resolver, err := newNameResolver(appConfig.Launchers)
+	if err != nil {
+		slog.Error("Error while setting up launchers", "error", err)
+		os.Exit(1)
+	}
+	processManager := proc.NewProcessManager(resolver)
 
 	// Set up the icon provider
+	matcher, err := display.NewMatcher(appConfig)
+	if err != nil {
+		slog.Error("Error while compiling icon rules", "error", err)
+		os.Exit(1)
+	}
 	iconCache := cache.NewCache()
-	iconProvider := display.NewIconProvider(processManager, display.AppToIconMap(appConfig.AppToIcon), iconCache)
+	desktopIndex := desktop.NewIndex(desktop.ApplicationDirs())
+	desktopIndex.Load()
+	iconProvider := display.NewIconProvider(processManager, matcher, iconCache, desktopIndex)
 
 	// Set up signal handling for SIGHUP (configuration reload)
 	sigChan := make(chan os.Signal, 1)
@@ -157,7 +178,13 @@ func run(appConfig *config.Config, appIconsConfigPath string, faIconsConfigPath
 		case sig := <-sigChan:
 			slog.Info("Received signal", "signal", sig)
 			if sig == syscall.SIGHUP {
-				newConfig, err := config.NewConfig(appIconsConfigPath, faIconsConfigPath, appConfig.Format)
+				newConfig, err := config.NewConfig(appIconsConfigPath, faIconsConfigPath, format)
+				if err != nil {
+					slog.Error("Failed to reload configuration", "error", err)
+					continue
+				}
+
+				resolver, err := newNameResolver(newConfig.Launchers)
 				if err != nil {
 					slog.Error("Failed to reload configuration", "error", err)
 					continue
@@ -165,12 +192,28 @@ func run(appConfig *config.Config, appIconsConfigPath string, faIconsConfigPath
 
 				if err := h.ReloadConfig(newConfig); err != nil {
 					slog.Error("Failed to reload configuration", "error", err)
+					continue
 				}
+				processManager.SetResolver(resolver)
+				desktopIndex.Load()
 			}
 		}
 	}
 }
 
+// newNameResolver creates the process name resolver unwrapping the configured launchers.
+func newNameResolver(launchers []config.Launcher) (*proc.ChainResolver, error) {
+	chain := make([]proc.Launcher, 0, len(launchers))
+	for _, launcher := range launchers {
+		l, err := proc.NewLauncher(launcher.Unwrap, launcher.Exe)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, l)
+	}
+	return proc.NewChainResolver(&proc.LinuxResolver{ProcPath: procPath}, chain), nil
+}
+
 // help prints the help message.
 func help() {
 	fmt.Fprintf(os.Stderr, `Renames sway workspaces by window names with Font Awesome icons.
@@ -188,8 +231,12 @@ Commands:
 Flags:
   -c         path to app-icons.yaml (auto-detect from ~/.config/sway or ~/.config/i3 if empty)
   -u         display only unique icons (default true)
+  -n         display unique icons with the window count, e.g. terminal×3
   -l         trim app names to this length, -1 = no trim (default 12)
+  -m         display at most this many icons, 0 = no limit (default 0)
+  -s         icon order: tree, position, alpha, focus or priority (default tree)
   -d         app separator (default "|")
+  -f         workspace name template, text/template syntax (default built from -d)
   -v         enable verbose/debug logging
 
 Configuration can be reloaded at runtime by sending SIGHUP signal:
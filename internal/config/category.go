@@ -0,0 +1,31 @@
+package config
+
+// defaultCategoryIcons maps the lowercase desktop entry categories to icon names.
+// These are used for applications having no explicit rule.
+var defaultCategoryIcons = map[string]string{
+	"webbrowser":       "globe",
+	"terminalemulator": "terminal",
+	"texteditor":       "edit",
+	"filemanager":      "folder-open",
+	"email":            "envelope",
+	"instantmessaging": "comment",
+	"chat":             "comment",
+	"wordprocessor":    "file-word",
+	"spreadsheet":      "table",
+	"presentation":     "file-powerpoint",
+	"ide":              "code",
+	"player":           "play",
+	"audiovideo":       "play",
+	"audio":            "music",
+	"video":            "film",
+	"development":      "code",
+	"education":        "graduation-cap",
+	"game":             "gamepad",
+	"graphics":         "image",
+	"network":          "network-wired",
+	"office":           "file-alt",
+	"science":          "flask",
+	"settings":         "cog",
+	"system":           "cog",
+	"utility":          "toolbox",
+}
@@ -4,7 +4,10 @@
 package config
 
 import (
+	"cmp"
 	"log/slog"
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -16,7 +19,37 @@ type AppToIconMap map[string]string
 // Config is a struct that contains the config for the app.
 type Config struct {
 	AppToIcon AppToIconMap
-	Format    *Format
+	// Rules are ordered by priority, the first matching rule wins.
+	Rules []Rule
+	// Ignore rules exclude the windows from the workspace names.
+	Ignore []IgnoreRule
+	// Launchers is the chain unwrapping launcher processes to the real application names.
+	Launchers []Launcher
+	// CategoryToIcon maps the lowercase desktop entry categories to icons.
+	CategoryToIcon map[string]string
+	// Fallback is the chain of steps for the windows nothing matches.
+	Fallback []string
+	// NoMatchIcon is the placeholder icon of the _no_match app name, empty when not configured.
+	NoMatchIcon string
+	Format      *Format
+	// Outputs override the format of the workspaces on the matching outputs, the first matching one wins.
+	Outputs []OutputFormat
+	// OutputIcons are the icons of the outputs prefixing the workspace names, the first matching one wins.
+	OutputIcons []OutputIcon
+}
+
+// appIconsConfig is the content of the app icons config file.
+// The sections are listed under their reserved keys, all the other keys are the legacy icon to app names map.
+type appIconsConfig struct {
+	Rules       []Rule               `mapstructure:"rules"`
+	Ignore      []IgnoreRule         `mapstructure:"ignore"`
+	Launchers   []Launcher           `mapstructure:"launchers"`
+	Categories  map[string]string    `mapstructure:"categories"`
+	Fallback    []string             `mapstructure:"fallback"`
+	Format      *Format              `mapstructure:"format"`
+	Outputs     []outputFormatConfig `mapstructure:"outputs"`
+	OutputIcons []OutputIcon         `mapstructure:"output_icons"`
+	Icons       IconToAppMap         `mapstructure:",remain"`
 }
 
 const (
@@ -88,6 +121,7 @@ var (
 )
 
 // NewConfig creates a new config for the app.
+// The format section of the app icons config overrides the given format.
 func NewConfig(appIconsConfigPath string, faIconsConfigPath string, format *Format) (*Config, error) {
 	if format == nil {
 		format = DefaultFormat()
@@ -95,6 +129,13 @@ func NewConfig(appIconsConfigPath string, faIconsConfigPath string, format *Form
 	}
 
 	iconConfig := defaultIconConfig
+	var rules []Rule
+	var ignore []IgnoreRule
+	launchers := defaultLaunchers
+	categories := maps.Clone(defaultCategoryIcons)
+	fallback := DefaultFallback()
+	var outputs []outputFormatConfig
+	var outputIcons []OutputIcon
 	faIcons := defaultFaIcons
 
 	if appIconsConfigPath == "" {
@@ -103,9 +144,30 @@ func NewConfig(appIconsConfigPath string, faIconsConfigPath string, format *Form
 		configFile, err := NewConfigLoader(appIconsConfigPath)
 		// if error just use default icons
 		if err == nil {
-			loadedIconConfig := &IconToAppMap{}
+			// Decode the format section over a copy to keep the given format intact for reloads
+			formatCopy := *format
+			formatCopy.LayoutMarkers = maps.Clone(format.LayoutMarkers)
+			loadedIconConfig := &appIconsConfig{Format: &formatCopy}
 			if err := configFile.Load(loadedIconConfig); err == nil {
-				iconConfig = *loadedIconConfig
+				iconConfig = loadedIconConfig.Icons
+				rules = loadedIconConfig.Rules
+				ignore = loadedIconConfig.Ignore
+				// An empty list disables unwrapping, a missing one keeps the defaults
+				if loadedIconConfig.Launchers != nil {
+					launchers = loadedIconConfig.Launchers
+				}
+				// Categories extend and override the built-in ones
+				for category, icon := range loadedIconConfig.Categories {
+					categories[strings.ToLower(category)] = icon
+				}
+				if loadedIconConfig.Fallback != nil {
+					fallback = loadedIconConfig.Fallback
+				}
+				if loadedIconConfig.Format != nil {
+					format = loadedIconConfig.Format
+				}
+				outputs = loadedIconConfig.Outputs
+				outputIcons = loadedIconConfig.OutputIcons
 			}
 		}
 	}
@@ -149,9 +211,138 @@ func NewConfig(appIconsConfigPath string, faIconsConfigPath string, format *Form
 		}
 	}
 
+	// The placeholder is not an app name to match
+	noMatchIcon := iconByAppName[NoMatch]
+	delete(iconByAppName, NoMatch)
+
+	resolvedOutputs, err := resolveOutputs(outputs, format, faIcons)
+	if err != nil {
+		return nil, err
+	}
+
 	currentConfig := &Config{
-		AppToIcon: iconByAppName,
-		Format:    format,
+		AppToIcon:      iconByAppName,
+		Rules:          resolveRules(rules, faIcons),
+		Ignore:         resolveIgnore(ignore),
+		Launchers:      launchers,
+		CategoryToIcon: resolveCategories(categories, faIcons),
+		Fallback:       resolveFallback(fallback),
+		NoMatchIcon:    noMatchIcon,
+		Format:         resolveFormat(format, faIcons),
+		Outputs:        resolvedOutputs,
+		OutputIcons:    resolveOutputIcons(outputIcons, faIcons),
 	}
 	return currentConfig, nil
 }
+
+// ScratchpadBadge reports whether the format or any output format shows the scratchpad badge.
+func (c *Config) ScratchpadBadge() bool {
+	return c.anyFormat(func(format *Format) bool {
+		return format.Scratchpad != ""
+	})
+}
+
+// Floating reports whether the format or any output format decorates or groups the floating windows.
+func (c *Config) Floating() bool {
+	return c.anyFormat(func(format *Format) bool {
+		return format.Floating != "" || format.GroupFloating
+	})
+}
+
+// Layout reports whether the format or any output format renders the container layout.
+func (c *Config) Layout() bool {
+	return c.anyFormat(func(format *Format) bool {
+		return format.Layout
+	})
+}
+
+// anyFormat reports whether the format or any output format satisfies the predicate.
+func (c *Config) anyFormat(predicate func(format *Format) bool) bool {
+	if c.Format != nil && predicate(c.Format) {
+		return true
+	}
+	return slices.ContainsFunc(c.Outputs, func(output OutputFormat) bool {
+		return predicate(output.Format)
+	})
+}
+
+// resolveRules replaces rule icon names with the icons and orders the rules by priority.
+// Rules with the same priority keep the order of the config file.
+// The rules and their sub-rules, resolved the same way, match the title unless set otherwise.
+func resolveRules(rules []Rule, faIcons map[string]string) []Rule {
+	resolved := make([]Rule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Match == "" {
+			rule.Match = MatchTitle
+		}
+		if !slices.Contains(MatchSources, rule.Match) {
+			slog.Warn("Unknown rule match source", "match", rule.Match, "pattern", rule.Pattern)
+			continue
+		}
+		faIcon, ok := faIcons[rule.Icon]
+		if !ok {
+			slog.Warn("FA icon not found", "icon", rule.Icon)
+			continue
+		}
+		rule.Icon = faIcon
+		if len(rule.Rules) > 0 {
+			rule.Rules = resolveRules(rule.Rules, faIcons)
+		}
+		resolved = append(resolved, rule)
+	}
+	slices.SortStableFunc(resolved, func(a, b Rule) int {
+		return cmp.Compare(b.Priority, a.Priority)
+	})
+	return resolved
+}
+
+// resolveIgnore drops the ignore rules with unknown match sources.
+func resolveIgnore(ignore []IgnoreRule) []IgnoreRule {
+	resolved := make([]IgnoreRule, 0, len(ignore))
+	for _, rule := range ignore {
+		if !slices.Contains(MatchSources, rule.Match) {
+			slog.Warn("Unknown ignore rule match source", "match", rule.Match, "pattern", rule.Pattern)
+			continue
+		}
+		resolved = append(resolved, rule)
+	}
+	return resolved
+}
+
+// resolveFormat copies the format with the priorities resolved, the given format is kept intact for reloads.
+func resolveFormat(format *Format, faIcons map[string]string) *Format {
+	resolved := *format
+	resolved.Priorities = resolvePriorities(format.Priorities, faIcons)
+	return &resolved
+}
+
+// resolvePriorities replaces the icon names of the priorities with the icons.
+func resolvePriorities(priorities map[string]int, faIcons map[string]string) map[string]int {
+	if priorities == nil {
+		return nil
+	}
+	resolved := make(map[string]int, len(priorities))
+	for icon, priority := range priorities {
+		faIcon, ok := faIcons[icon]
+		if !ok {
+			slog.Warn("FA icon not found", "icon", icon)
+			continue
+		}
+		resolved[faIcon] = priority
+	}
+	return resolved
+}
+
+// resolveCategories replaces category icon names with the icons.
+func resolveCategories(categories map[string]string, faIcons map[string]string) map[string]string {
+	resolved := make(map[string]string, len(categories))
+	for category, icon := range categories {
+		faIcon, ok := faIcons[icon]
+		if !ok {
+			slog.Debug("FA icon not found for category", "category", category, "icon", icon)
+			continue
+		}
+		resolved[category] = faIcon
+	}
+	return resolved
+}
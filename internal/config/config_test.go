@@ -0,0 +1,328 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfig writes the config file content to the temporary directory and returns its path.
+func writeConfig(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestNewConfig_IconMap(t *testing.T) {
+	appIconsPath := writeConfig(t, AppIconsFileName, `
+terminal:
+  - Foot
+  - kitty
+`)
+	faIconsPath := writeConfig(t, FaFileName, `terminal: \uf120`)
+
+	cfg, err := NewConfig(appIconsPath, faIconsPath, DefaultFormat())
+	require.NoError(t, err)
+	assert.Equal(t, AppToIconMap{"foot": "\uf120", "kitty": "\uf120"}, cfg.AppToIcon)
+	assert.Empty(t, cfg.Rules)
+}
+
+func TestNewConfig_Rules(t *testing.T) {
+	appIconsPath := writeConfig(t, AppIconsFileName, `
+rules:
+  - icon: terminal
+    match: app_id
+    pattern: foot
+    color: '#00ff00'
+  - icon: edit
+    match: title
+    pattern: ' - NVIM$'
+    priority: 10
+    rules:
+      - icon: terminal
+        pattern: '^term://'
+      - icon: missing
+        pattern: skipped
+  - icon: edit
+    match: unknown
+    pattern: skipped
+  - icon: code
+    pattern: ' - VSCodium$'
+  - icon: missing
+    match: class
+    pattern: skipped
+ignore:
+  - match: floating
+    pattern: 'true'
+  - match: unknown
+    pattern: skipped
+code:
+  - code
+`)
+	faIconsPath := writeConfig(t, FaFileName, `
+terminal: \uf120
+edit: \uf044
+code: \uf121
+`)
+
+	cfg, err := NewConfig(appIconsPath, faIconsPath, DefaultFormat())
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{
+		{Icon: "\uf044", Match: MatchTitle, Pattern: " - NVIM$", Priority: 10, Rules: []Rule{
+			{Icon: "\uf120", Match: MatchTitle, Pattern: "^term://"},
+		}},
+		{Icon: "\uf120", Match: MatchAppID, Pattern: "foot", Color: "#00ff00"},
+		{Icon: "\uf121", Match: MatchTitle, Pattern: " - VSCodium$"},
+	}, cfg.Rules, "a rule without match matches the title")
+	assert.Equal(t, []IgnoreRule{{Match: MatchFloating, Pattern: "true"}}, cfg.Ignore)
+	assert.Equal(t, AppToIconMap{"code": "\uf121"}, cfg.AppToIcon)
+}
+
+func TestNewConfig_Launchers(t *testing.T) {
+	cfg, err := NewConfig(writeConfig(t, AppIconsFileName, `terminal: [foot]`), "", DefaultFormat())
+	require.NoError(t, err)
+	assert.Equal(t, defaultLaunchers, cfg.Launchers)
+
+	cfg, err = NewConfig(writeConfig(t, AppIconsFileName, `launchers: []`), "", DefaultFormat())
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Launchers)
+
+	cfg, err = NewConfig(writeConfig(t, AppIconsFileName, `
+launchers:
+  - unwrap: script
+    exe: [python3]
+`), "", DefaultFormat())
+	require.NoError(t, err)
+	assert.Equal(t, []Launcher{{Unwrap: "script", Exe: []string{"python3"}}}, cfg.Launchers)
+}
+
+func TestNewConfig_Categories(t *testing.T) {
+	appIconsPath := writeConfig(t, AppIconsFileName, `
+categories:
+  TerminalEmulator: code
+  Game: missing
+`)
+	faIconsPath := writeConfig(t, FaFileName, `
+code: \uf121
+globe: \uf0ac
+`)
+
+	cfg, err := NewConfig(appIconsPath, faIconsPath, DefaultFormat())
+	require.NoError(t, err)
+	assert.Equal(t, "\uf121", cfg.CategoryToIcon["terminalemulator"], "user category overrides the default")
+	assert.Equal(t, "\uf0ac", cfg.CategoryToIcon["webbrowser"], "default category")
+	assert.NotContains(t, cfg.CategoryToIcon, "game", "missing icon")
+	assert.Empty(t, cfg.AppToIcon)
+}
+
+func TestNewConfig_Fallback(t *testing.T) {
+	faIconsPath := writeConfig(t, FaFileName, `question-circle: \uf059`)
+
+	cfg, err := NewConfig(writeConfig(t, AppIconsFileName, `question-circle: [_no_match]`), faIconsPath, DefaultFormat())
+	require.NoError(t, err)
+	assert.Equal(t, DefaultFallback(), cfg.Fallback)
+	assert.Equal(t, "\uf059", cfg.NoMatchIcon)
+	assert.NotContains(t, cfg.AppToIcon, NoMatch)
+
+	cfg, err = NewConfig(writeConfig(t, AppIconsFileName, `fallback: [placeholder, unknown, hide]`), faIconsPath, DefaultFormat())
+	require.NoError(t, err)
+	assert.Equal(t, []string{FallbackPlaceholder, FallbackHide}, cfg.Fallback)
+	assert.Empty(t, cfg.NoMatchIcon)
+}
+
+func TestNewConfig_Format(t *testing.T) {
+	format := DefaultFormat()
+	format.Delimiter = " "
+
+	cfg, err := NewConfig(writeConfig(t, AppIconsFileName, `
+format:
+  template: '{{.Number}} {{join .Icons ""}}'
+  length: 5
+  count: true
+  sort: priority
+  markup: pango
+  layout: true
+  layout_markers:
+    tabbed: '<{children}>'
+  scratchpad: 'S{count}'
+  scratchpad_workspace: 10
+  priorities:
+    terminal: 10
+    missing: 5
+`), writeConfig(t, FaFileName, `terminal: \uf120`), format)
+	require.NoError(t, err)
+	assert.Equal(t, &Format{
+		Length:      5,
+		Delimiter:   " ",
+		Uniq:        true,
+		Count:       true,
+		CountFormat: DefaultCountFormat,
+		Overflow:    DefaultOverflow,
+		Keep:        KeepFirst,
+		Sort:        SortPriority,
+		Markup:      MarkupPango,
+		Layout:      true,
+		LayoutMarkers: map[string]string{
+			"splith":  "[{children}]",
+			"splitv":  "[{children}]",
+			"tabbed":  "<{children}>",
+			"stacked": "S({children})",
+		},
+		Scratchpad:          "S{count}",
+		ScratchpadWorkspace: 10,
+		Priorities:          map[string]int{"\uf120": 10},
+		Template:            `{{.Number}} {{join .Icons ""}}`,
+	}, cfg.Format)
+	assert.Equal(t, DefaultLength, format.Length, "the given format is kept intact")
+	assert.Equal(t, DefaultLayoutMarkers(), format.LayoutMarkers, "the given layout markers are kept intact")
+
+	cfg, err = NewConfig(writeConfig(t, AppIconsFileName, `terminal: [foot]`), "", format)
+	require.NoError(t, err)
+	assert.Equal(t, format, cfg.Format)
+}
+
+func TestConfig_ScratchpadBadge(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *Config
+		expected bool
+	}{
+		{name: "none", config: &Config{Format: DefaultFormat()}, expected: false},
+		{name: "format", config: &Config{Format: &Format{Scratchpad: "S{count}"}}, expected: true},
+		{
+			name:     "output",
+			config:   &Config{Format: DefaultFormat(), Outputs: []OutputFormat{{Match: "eDP-*", Format: &Format{Scratchpad: "S{count}"}}}},
+			expected: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.config.ScratchpadBadge())
+		})
+	}
+}
+
+func TestConfig_Floating(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *Config
+		expected bool
+	}{
+		{name: "none", config: &Config{Format: DefaultFormat()}, expected: false},
+		{name: "decoration", config: &Config{Format: &Format{Floating: "~{icon}"}}, expected: true},
+		{name: "group", config: &Config{Format: &Format{GroupFloating: true}}, expected: true},
+		{
+			name:     "output",
+			config:   &Config{Format: DefaultFormat(), Outputs: []OutputFormat{{Match: "eDP-*", Format: &Format{Floating: "~{icon}"}}}},
+			expected: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.config.Floating())
+		})
+	}
+}
+
+func TestConfig_Layout(t *testing.T) {
+	assert.False(t, (&Config{Format: DefaultFormat()}).Layout())
+	assert.True(t, (&Config{Format: &Format{Layout: true}}).Layout())
+	assert.True(t, (&Config{Format: DefaultFormat(), Outputs: []OutputFormat{{Match: "DP-3", Format: &Format{Layout: true}}}}).Layout())
+}
+
+func TestNewConfig_Outputs(t *testing.T) {
+	format := DefaultFormat()
+
+	cfg, err := NewConfig(writeConfig(t, AppIconsFileName, `
+format:
+  length: 8
+  priorities:
+    terminal: 10
+outputs:
+  - match: 'eDP-*'
+    format:
+      length: 3
+      template: '{{.Number}}{{join .Icons ""}}'
+      layout_markers:
+        tabbed: '<{children}>'
+  - match: DP-3
+    format:
+      priorities:
+        terminal: 1
+`), writeConfig(t, FaFileName, `terminal: \uf120`), format)
+	require.NoError(t, err)
+	require.Len(t, cfg.Outputs, 2)
+
+	assert.Equal(t, "eDP-*", cfg.Outputs[0].Match)
+	assert.Equal(t, 3, cfg.Outputs[0].Format.Length)
+	assert.Equal(t, `{{.Number}}{{join .Icons ""}}`, cfg.Outputs[0].Format.Template)
+	assert.Equal(t, "<{children}>", cfg.Outputs[0].Format.LayoutMarkers["tabbed"])
+	assert.Equal(t, DefaultDelimiter, cfg.Outputs[0].Format.Delimiter, "the format section is the default")
+	assert.Equal(t, map[string]int{"\uf120": 10}, cfg.Outputs[0].Format.Priorities)
+
+	assert.Equal(t, "DP-3", cfg.Outputs[1].Match)
+	assert.Equal(t, 8, cfg.Outputs[1].Format.Length)
+	assert.Equal(t, map[string]int{"\uf120": 1}, cfg.Outputs[1].Format.Priorities)
+
+	assert.Equal(t, 8, cfg.Format.Length)
+	assert.Equal(t, "T({children})", cfg.Format.LayoutMarkers["tabbed"], "the format section is kept intact")
+	assert.Equal(t, DefaultLayoutMarkers(), format.LayoutMarkers, "the given format is kept intact")
+}
+
+func TestNewConfig_Outputs_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		outputs string
+	}{
+		{
+			name: "glob",
+			outputs: `
+  - match: '[invalid'
+    format:
+      length: 1`,
+		},
+		{
+			name: "unknown key",
+			outputs: `
+  - match: 'eDP-*'
+    format:
+      lenght: 1`,
+		},
+		{
+			name: "type",
+			outputs: `
+  - match: 'eDP-*'
+    format:
+      length: [1]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewConfig(writeConfig(t, AppIconsFileName, "outputs:"+tt.outputs), "", DefaultFormat())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewConfig_OutputIcons(t *testing.T) {
+	cfg, err := NewConfig(writeConfig(t, AppIconsFileName, `
+output_icons:
+  - icon: laptop
+    pattern: '^eDP-'
+  - icon: 'L'
+    match: serial
+    pattern: '0x0000A1B2'
+  - icon: tv
+    match: resolution
+    pattern: '4k'
+`), writeConfig(t, FaFileName, `laptop: \uf109`), DefaultFormat())
+	require.NoError(t, err)
+	assert.Equal(t, []OutputIcon{
+		{Icon: "\uf109", Match: OutputMatchName, Pattern: "^eDP-"},
+		{Icon: "L", Match: OutputMatchSerial, Pattern: "0x0000A1B2"},
+	}, cfg.OutputIcons)
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"log/slog"
+	"slices"
+)
+
+// Fallback steps for the windows nothing matches.
+const (
+	// FallbackPlaceholder shows the icon of the _no_match app name.
+	FallbackPlaceholder = "placeholder"
+	// FallbackName shows the desktop entry name.
+	FallbackName    = "name"
+	FallbackAppID   = "app_id"
+	FallbackProcess = "process"
+	// FallbackTitle shows the window title trimmed to the format length.
+	FallbackTitle = "title"
+	// FallbackHide leaves the window out of the workspace name.
+	FallbackHide = "hide"
+)
+
+// FallbackSteps lists all the supported fallback steps.
+var FallbackSteps = []string{FallbackPlaceholder, FallbackName, FallbackAppID, FallbackProcess, FallbackTitle, FallbackHide}
+
+// DefaultFallback returns the default fallback chain: the desktop entry name, then the window title.
+func DefaultFallback() []string {
+	return []string{FallbackName, FallbackTitle}
+}
+
+// resolveFallback drops the unknown fallback steps.
+func resolveFallback(fallback []string) []string {
+	resolved := make([]string, 0, len(fallback))
+	for _, step := range fallback {
+		if !slices.Contains(FallbackSteps, step) {
+			slog.Warn("Unknown fallback step", "step", step)
+			continue
+		}
+		resolved = append(resolved, step)
+	}
+	return resolved
+}
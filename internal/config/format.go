@@ -4,16 +4,121 @@ const (
 	DefaultLength    = 12
 	DefaultDelimiter = "|"
 	DefaultUniq      = true
+	// DefaultCountFormat is the suffix of the collapsed icons, e.g. "terminal×3".
+	DefaultCountFormat = "\u00d7{count}"
+	// DefaultOverflow is the marker of the icons beyond MaxIcons, e.g. "+4".
+	DefaultOverflow = "+{count}"
 )
 
+// Orders choosing the icons kept within MaxIcons.
+const (
+	// KeepFirst keeps the icons seen first.
+	KeepFirst = "first"
+	// KeepFocused keeps the icon of the focused window first.
+	KeepFocused = "focused"
+	// KeepFrequent keeps the icons of the most windows first.
+	KeepFrequent = "frequent"
+)
+
+// KeepOrders lists all the supported keep orders.
+var KeepOrders = []string{KeepFirst, KeepFocused, KeepFrequent}
+
+// Orders of the icons within the workspace name.
+const (
+	// SortTree keeps the order of the windows in the sway tree.
+	SortTree = "tree"
+	// SortPosition orders the icons by the window position on the screen, left to right and top to bottom.
+	SortPosition = "position"
+	// SortAlpha orders the icons alphabetically by the app_id, class or the icon itself.
+	SortAlpha = "alpha"
+	// SortFocus orders the icons by the focus history, the most recently focused first.
+	SortFocus = "focus"
+	// SortPriority orders the icons by their Priorities, the highest first.
+	SortPriority = "priority"
+)
+
+// SortOrders lists all the supported sort orders.
+var SortOrders = []string{SortTree, SortPosition, SortAlpha, SortFocus, SortPriority}
+
+// Markups of the workspace name.
+const (
+	// MarkupNone renders the plain text.
+	MarkupNone = "none"
+	// MarkupPango escapes the text and styles the icons with Pango spans, the bar needs pango_markup enabled.
+	MarkupPango = "pango"
+)
+
+// Markups lists all the supported markups.
+var Markups = []string{MarkupNone, MarkupPango}
+
+// DefaultLayoutMarkers returns the default markers of the container layouts, {children} are the rendered children,
+// e.g. "[firefox|term]" for a horizontal split or "T(a|b|c)" for a tabbed container.
+func DefaultLayoutMarkers() map[string]string {
+	return map[string]string{
+		"splith":  "[{children}]",
+		"splitv":  "[{children}]",
+		"tabbed":  "T({children})",
+		"stacked": "S({children})",
+	}
+}
+
 // Format is a struct that contains the format config for the workspace name.
 type Format struct {
-	Length    int
-	Delimiter string
-	Uniq      bool
+	// Length trims the app names, -1 means no trim.
+	Length    int    `mapstructure:"length"`
+	Delimiter string `mapstructure:"delimiter"`
+	Uniq      bool   `mapstructure:"uniq"`
+	// Count collapses the duplicate icons adding the CountFormat suffix, it takes precedence over Uniq.
+	Count bool `mapstructure:"count"`
+	// CountFormat is the suffix template with the {count} or {superscript} number of windows.
+	CountFormat string `mapstructure:"count_format"`
+	// MaxIcons caps the number of icons, 0 means no limit.
+	MaxIcons int `mapstructure:"max_icons"`
+	// Overflow replaces the icons beyond the caps, {count} is the number of them.
+	Overflow string `mapstructure:"overflow"`
+	// Keep is the order choosing the icons kept within MaxIcons.
+	Keep string `mapstructure:"keep"`
+	// Sort is the order of the icons, one of SortOrders.
+	Sort string `mapstructure:"sort"`
+	// Focused decorates the icon of the focused window, e.g. "[{icon}]", empty keeps the icon as is.
+	Focused string `mapstructure:"focused"`
+	// Urgent decorates the icon of the urgent window, a template without {icon} replaces the icon.
+	Urgent string `mapstructure:"urgent"`
+	// Fullscreen, Floating and Sticky decorate the icons of the windows in these states.
+	Fullscreen string `mapstructure:"fullscreen"`
+	Floating   string `mapstructure:"floating"`
+	Sticky     string `mapstructure:"sticky"`
+	// GroupFloating puts the floating windows after the tiled ones.
+	GroupFloating bool `mapstructure:"group_floating"`
+	// Markup is one of Markups, pango escapes the window text and keeps the templates as markup.
+	Markup string `mapstructure:"markup"`
+	// Template is the text/template of the workspace name, empty builds the default one from the delimiter.
+	Template string `mapstructure:"template"`
+	// Layout renders the icons in the container tree of the workspace.
+	Layout bool `mapstructure:"layout"`
+	// LayoutMarkers are keyed by the sway layouts: splith, splitv, tabbed and stacked.
+	LayoutMarkers map[string]string `mapstructure:"layout_markers"`
+	// LayoutWidth caps the width of the rendered layout, 0 means no limit.
+	LayoutWidth int `mapstructure:"layout_width"`
+	// Scratchpad is the badge template of the scratchpad windows with their {count} and {icons}.
+	Scratchpad string `mapstructure:"scratchpad"`
+	// ScratchpadWorkspace is the workspace number showing the badge, 0 means the focused one.
+	ScratchpadWorkspace int64 `mapstructure:"scratchpad_workspace"`
+	// Priorities are keyed by the icon names in the config file and by the icons once the config is loaded.
+	Priorities map[string]int `mapstructure:"priorities"`
 }
 
 // DefaultFormat returns the default format config.
 func DefaultFormat() *Format {
-	return &Format{Length: DefaultLength, Delimiter: DefaultDelimiter, Uniq: DefaultUniq}
+	return &Format{
+		Length:        DefaultLength,
+		Delimiter:     DefaultDelimiter,
+		Uniq:          DefaultUniq,
+		CountFormat:   DefaultCountFormat,
+		Overflow:      DefaultOverflow,
+		Keep:          KeepFirst,
+		Sort:          SortTree,
+		Markup:        MarkupNone,
+		LayoutMarkers: DefaultLayoutMarkers(),
+	}
 }
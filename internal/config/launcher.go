@@ -0,0 +1,16 @@
+package config
+
+// Launcher configures unwrapping of a launcher process to the real application name.
+// Unwrap is the name of the built-in unwrapper, Exe lists regexes of the launcher executable names.
+type Launcher struct {
+	Unwrap string   `mapstructure:"unwrap"`
+	Exe    []string `mapstructure:"exe"`
+}
+
+// defaultLaunchers is the default launcher unwrapping chain.
+var defaultLaunchers = []Launcher{
+	{Unwrap: "java", Exe: []string{"java"}},
+	{Unwrap: "script", Exe: []string{`python[0-9.]*`, `node(js)?`, `ruby[0-9.]*`, `perl[0-9.]*`}},
+	{Unwrap: "wine", Exe: []string{`wine(64)?(-preloader)?`}},
+	{Unwrap: "electron", Exe: []string{`electron[0-9]*`}},
+}
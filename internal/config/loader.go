@@ -46,3 +46,13 @@ func (c *ConfigLoader) Load(target any) error {
 
 	return nil
 }
+
+// decodeOverrides decodes the config keys over the target the same way the config file is loaded.
+// Unknown keys are reported as an error.
+func decodeOverrides(overrides map[string]any, target any) error {
+	v := viper.New()
+	if err := v.MergeConfigMap(overrides); err != nil {
+		return err
+	}
+	return v.UnmarshalExact(target)
+}
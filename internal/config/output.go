@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"path"
+	"slices"
+)
+
+// Output properties an output icon can be matched against.
+const (
+	OutputMatchName   = "name"
+	OutputMatchMake   = "make"
+	OutputMatchModel  = "model"
+	OutputMatchSerial = "serial"
+)
+
+// OutputMatchSources lists all the supported output icon match sources.
+var OutputMatchSources = []string{OutputMatchName, OutputMatchMake, OutputMatchModel, OutputMatchSerial}
+
+// OutputIcon is the icon of the outputs with the property matching the pattern, e.g. a laptop for "eDP-1".
+// The pattern is a case insensitive regex, the icon is a Font Awesome icon name or a short label.
+type OutputIcon struct {
+	Icon    string `mapstructure:"icon"`
+	Match   string `mapstructure:"match"`
+	Pattern string `mapstructure:"pattern"`
+}
+
+// OutputFormat overrides the format of the workspaces on the outputs matching the glob, e.g. "eDP-*" or "DP-3".
+type OutputFormat struct {
+	Match string
+	// Format is the format section with the overrides applied.
+	Format *Format
+}
+
+// outputFormatConfig is an output format override as listed in the config file,
+// the format keys are decoded over the format section once it is known.
+type outputFormatConfig struct {
+	Match  string         `mapstructure:"match"`
+	Format map[string]any `mapstructure:"format"`
+}
+
+// resolveOutputs applies the overrides of the outputs over the format and resolves the resulting formats.
+// An invalid glob or format key is reported as an error.
+func resolveOutputs(outputs []outputFormatConfig, format *Format, faIcons map[string]string) ([]OutputFormat, error) {
+	resolved := make([]OutputFormat, 0, len(outputs))
+	for _, output := range outputs {
+		if _, err := path.Match(output.Match, ""); err != nil {
+			return nil, fmt.Errorf("invalid output glob %q: %w", output.Match, err)
+		}
+		outputFormat := *format
+		outputFormat.LayoutMarkers = maps.Clone(format.LayoutMarkers)
+		outputFormat.Priorities = maps.Clone(format.Priorities)
+		if err := decodeOverrides(output.Format, &outputFormat); err != nil {
+			return nil, fmt.Errorf("invalid output %q format: %w", output.Match, err)
+		}
+		resolved = append(resolved, OutputFormat{
+			Match:  output.Match,
+			Format: resolveFormat(&outputFormat, faIcons),
+		})
+	}
+	return resolved, nil
+}
+
+// resolveOutputIcons replaces the icon names of the output icons with the icons, the other icons are the labels.
+// The output icons with unknown match sources are dropped, the name is matched by default.
+func resolveOutputIcons(outputIcons []OutputIcon, faIcons map[string]string) []OutputIcon {
+	resolved := make([]OutputIcon, 0, len(outputIcons))
+	for _, outputIcon := range outputIcons {
+		if outputIcon.Match == "" {
+			outputIcon.Match = OutputMatchName
+		}
+		if !slices.Contains(OutputMatchSources, outputIcon.Match) {
+			slog.Warn("Unknown output icon match source", "match", outputIcon.Match, "pattern", outputIcon.Pattern)
+			continue
+		}
+		if faIcon, ok := faIcons[outputIcon.Icon]; ok {
+			outputIcon.Icon = faIcon
+		}
+		resolved = append(resolved, outputIcon)
+	}
+	return resolved
+}
@@ -0,0 +1,50 @@
+package config
+
+// Window properties a rule can be matched against.
+const (
+	MatchTitle    = "title"
+	MatchAppID    = "app_id"
+	MatchClass    = "class"
+	MatchInstance = "instance"
+	MatchProcess  = "process"
+	// MatchCmdline matches the full process command line, arguments are joined with spaces.
+	MatchCmdline = "cmdline"
+	// MatchCgroup matches the application ID derived from the process cgroup,
+	// e.g. "org.mozilla.firefox" for Flatpak or "snap.spotify" for Snap.
+	MatchCgroup = "cgroup"
+	// MatchDesktop matches the XDG desktop entry ID, e.g. "org.gnome.Nautilus".
+	MatchDesktop = "desktop"
+	// MatchFloating matches "true" for the floating windows.
+	MatchFloating = "floating"
+)
+
+// MatchSources lists all the supported rule match sources.
+var MatchSources = []string{MatchTitle, MatchAppID, MatchClass, MatchInstance, MatchProcess, MatchCmdline, MatchCgroup, MatchDesktop, MatchFloating}
+
+// Rule is an icon rule matching a single window property against the pattern.
+// The pattern is either the exact property value or a regex, both are case insensitive.
+// Descend makes the rule look for the foreground process running in the window,
+// e.g. the editor in a terminal, and prefer its icon when there is one.
+// Label is a template such as "{icon} {file}" rendered with the named captures of the pattern.
+// Rules are the sub-rules refining the icon of the matched window, they match the title by default.
+// Color, Font, Size and Rise are the Pango span attributes of the icon used with the pango markup format.
+type Rule struct {
+	Icon     string `mapstructure:"icon"`
+	Match    string `mapstructure:"match"`
+	Pattern  string `mapstructure:"pattern"`
+	Priority int    `mapstructure:"priority"`
+	Descend  bool   `mapstructure:"descend"`
+	Label    string `mapstructure:"label"`
+	Rules    []Rule `mapstructure:"rules"`
+	Color    string `mapstructure:"color"`
+	Font     string `mapstructure:"font"`
+	Size     string `mapstructure:"size"`
+	Rise     string `mapstructure:"rise"`
+}
+
+// IgnoreRule leaves the windows matching a single property against the pattern out of the workspace names,
+// e.g. dialogs and popups. The pattern is matched the same way as the rule one.
+type IgnoreRule struct {
+	Match   string `mapstructure:"match"`
+	Pattern string `mapstructure:"pattern"`
+}
@@ -0,0 +1,227 @@
+// Package desktop provides an index of the XDG desktop entries
+// to resolve windows to their canonical application identity.
+
+package desktop
+
+import (
+	"bufio"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	// desktopExt is the desktop entry file extension.
+	desktopExt = ".desktop"
+	// mainGroup is the desktop entry group we are interested in.
+	mainGroup = "[Desktop Entry]"
+)
+
+// genericExecs are the Exec binaries that do not identify the application.
+var genericExecs = []string{"flatpak", "sh", "bash", "gtk-launch", "xdg-open"}
+
+// Entry is a desktop entry of an application.
+type Entry struct {
+	// ID is the desktop file ID without the extension, e.g. "org.gnome.Nautilus".
+	ID   string
+	Name string
+	// Exec is the base name of the executable from the Exec key.
+	Exec           string
+	StartupWMClass string
+	Categories     []string
+}
+
+// Index indexes the desktop entries by the desktop ID, StartupWMClass and Exec binary.
+type Index struct {
+	mu        sync.RWMutex
+	dirs      []string
+	byID      map[string]*Entry
+	byWMClass map[string]*Entry
+	byExec    map[string]*Entry
+}
+
+// NewIndex creates a new empty Index of the given applications directories.
+// Entries of the former directories take precedence.
+func NewIndex(dirs []string) *Index {
+	return &Index{
+		dirs:      dirs,
+		byID:      make(map[string]*Entry),
+		byWMClass: make(map[string]*Entry),
+		byExec:    make(map[string]*Entry),
+	}
+}
+
+// ApplicationDirs returns the XDG applications directories in the order of precedence:
+// $XDG_DATA_HOME/applications (~/.local/share/applications) and then $XDG_DATA_DIRS/applications.
+func ApplicationDirs() []string {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		if usr, err := user.Current(); err == nil {
+			dataHome = filepath.Join(usr.HomeDir, ".local", "share")
+		}
+	}
+	dataDirs := os.Getenv("XDG_DATA_DIRS")
+	if dataDirs == "" {
+		dataDirs = "/usr/local/share:/usr/share"
+	}
+
+	var dirs []string
+	for _, dir := range append([]string{dataHome}, filepath.SplitList(dataDirs)...) {
+		if dir != "" {
+			dirs = append(dirs, filepath.Join(dir, "applications"))
+		}
+	}
+	return dirs
+}
+
+// Load (re)builds the index from the desktop files.
+func (i *Index) Load() {
+	byID := make(map[string]*Entry)
+	byWMClass := make(map[string]*Entry)
+	byExec := make(map[string]*Entry)
+	// seen holds the IDs of the former directories including hidden ones to let them shadow the latter.
+	seen := make(map[string]struct{})
+
+	for _, dir := range i.dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, desktopExt) {
+				return nil
+			}
+			// The desktop file ID is the path relative to the applications directory with "/" replaced by "-".
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return nil
+			}
+			id := strings.ReplaceAll(strings.TrimSuffix(rel, desktopExt), string(filepath.Separator), "-")
+			if _, exists := seen[id]; exists {
+				return nil
+			}
+			seen[id] = struct{}{}
+			entry, ok := parseEntry(path)
+			if !ok {
+				return nil
+			}
+			entry.ID = id
+			addEntry(byID, id, entry)
+			addEntry(byWMClass, entry.StartupWMClass, entry)
+			if !slices.Contains(genericExecs, entry.Exec) {
+				addEntry(byExec, entry.Exec, entry)
+			}
+			return nil
+		})
+		if err != nil {
+			slog.Debug("Error while reading desktop entries", "dir", dir, "error", err)
+		}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.byID = byID
+	i.byWMClass = byWMClass
+	i.byExec = byExec
+	slog.Debug("Desktop entries are loaded", "count", len(byID))
+}
+
+// Lookup looks up the desktop entry by the window app_id, X11 class and the process name.
+func (i *Index) Lookup(appID string, class string, process string) (*Entry, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	for _, lookup := range []struct {
+		entries map[string]*Entry
+		key     string
+	}{
+		{i.byID, appID},
+		{i.byWMClass, appID},
+		{i.byWMClass, class},
+		{i.byID, class},
+		{i.byExec, process},
+	} {
+		if lookup.key == "" {
+			continue
+		}
+		if entry, ok := lookup.entries[strings.ToLower(lookup.key)]; ok {
+			return entry, true
+		}
+	}
+	return nil, false
+}
+
+// addEntry adds the entry by the lowercase key unless there is one already.
+func addEntry(entries map[string]*Entry, key string, entry *Entry) {
+	key = strings.ToLower(key)
+	if key == "" {
+		return
+	}
+	if _, exists := entries[key]; !exists {
+		entries[key] = entry
+	}
+}
+
+// parseEntry parses the desktop file, hidden entries and the ones that are not applications are skipped.
+func parseEntry(path string) (*Entry, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		slog.Debug("Error while opening desktop entry", "path", path, "error", err)
+		return nil, false
+	}
+	defer file.Close()
+
+	entry := &Entry{}
+	inMainGroup := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			inMainGroup = line == mainGroup
+			continue
+		}
+		if !inMainGroup {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "Type":
+			if value != "Application" {
+				return nil, false
+			}
+		case "Hidden":
+			if value == "true" {
+				return nil, false
+			}
+		case "Name":
+			entry.Name = value
+		case "Exec":
+			entry.Exec = execBinary(value)
+		case "StartupWMClass":
+			entry.StartupWMClass = value
+		case "Categories":
+			entry.Categories = strings.FieldsFunc(value, func(r rune) bool { return r == ';' })
+		}
+	}
+	return entry, scanner.Err() == nil
+}
+
+// execBinary returns the base name of the executable from the Exec value
+// skipping the env command and the environment variables.
+func execBinary(exec string) string {
+	for _, field := range strings.Fields(exec) {
+		field = strings.Trim(field, `"'`)
+		if field == "env" || strings.Contains(field, "=") {
+			continue
+		}
+		return filepath.Base(field)
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package desktop
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeEntry writes the desktop file to the applications directory.
+func writeEntry(t *testing.T, dir string, name string, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestIndex_Lookup(t *testing.T) {
+	userDir, systemDir := t.TempDir(), t.TempDir()
+	writeEntry(t, systemDir, "org.xfce.mousepad.desktop", `[Desktop Entry]
+Type=Application
+Name=Mousepad
+Name[de]=Mausblock
+Exec=mousepad %U
+Categories=GTK;Utility;TextEditor;
+
+[Desktop Action new-window]
+Name=New Window
+Exec=mousepad --new-window
+`)
+	writeEntry(t, systemDir, "jetbrains-idea.desktop", `[Desktop Entry]
+Type=Application
+Name=IntelliJ IDEA
+Exec="/opt/idea/bin/idea.sh" %f
+StartupWMClass=jetbrains-idea
+`)
+	writeEntry(t, systemDir, "kde/org.kde.dolphin.desktop", `[Desktop Entry]
+Type=Application
+Name=Dolphin
+Exec=env QT_QPA_PLATFORM=wayland dolphin
+`)
+	writeEntry(t, systemDir, "vlc.desktop", `[Desktop Entry]
+Type=Application
+Name=VLC
+Exec=vlc
+`)
+	// The user entry hides the system one.
+	writeEntry(t, userDir, "vlc.desktop", `[Desktop Entry]
+Hidden=true
+`)
+	writeEntry(t, userDir, "org.xfce.mousepad.desktop", `[Desktop Entry]
+Type=Application
+Name=My Mousepad
+Exec=mousepad
+`)
+
+	index := NewIndex([]string{userDir, systemDir, filepath.Join(systemDir, "missing")})
+	index.Load()
+
+	testCases := []struct {
+		name                  string
+		appID, class, process string
+		expectedID            string
+		expectedName          string
+	}{
+		{name: "app_id", appID: "org.xfce.Mousepad", expectedID: "org.xfce.mousepad", expectedName: "My Mousepad"},
+		{name: "StartupWMClass", class: "jetbrains-idea", process: "java", expectedID: "jetbrains-idea", expectedName: "IntelliJ IDEA"},
+		{name: "exec binary", process: "dolphin", expectedID: "kde-org.kde.dolphin", expectedName: "Dolphin"},
+		{name: "hidden", process: "vlc"},
+		{name: "unknown", appID: "foot", class: "foot", process: "foot"},
+	}
+	for _, testCase := range testCases {
+		entry, ok := index.Lookup(testCase.appID, testCase.class, testCase.process)
+		assert.Equal(t, testCase.expectedID != "", ok, testCase.name)
+		if ok {
+			assert.Equal(t, testCase.expectedID, entry.ID, testCase.name)
+			assert.Equal(t, testCase.expectedName, entry.Name, testCase.name)
+		}
+	}
+}
+
+func TestParseEntry_Categories(t *testing.T) {
+	dir := t.TempDir()
+	writeEntry(t, dir, "firefox.desktop", `[Desktop Entry]
+Type=Application
+Name=Firefox
+Categories=Network;WebBrowser;
+`)
+	entry, ok := parseEntry(filepath.Join(dir, "firefox.desktop"))
+	require.True(t, ok)
+	assert.Equal(t, []string{"Network", "WebBrowser"}, entry.Categories)
+}
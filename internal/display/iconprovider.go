@@ -3,9 +3,12 @@
 package display
 
 import (
+	"cmp"
 	"log/slog"
-	"regexp"
+	"slices"
 	"strings"
+	"sway-icon-to-go/internal/config"
+	"sway-icon-to-go/internal/desktop"
 	"sway-icon-to-go/internal/workspace"
 	"sync"
 )
@@ -17,39 +20,73 @@ type AppToIconMap map[string]string
 type IconProvider struct {
 	mu             sync.RWMutex
 	processManager ProcessManager
-	iconMap        AppToIconMap
+	matcher        *Matcher
 	cache          IconCache
+	desktopEntries DesktopEntries
+}
+
+// legacySources is the order the window properties are looked up in the icon map.
+// The stable identifiers go first, the title tends to change all the time.
+var legacySources = []string{
+	config.MatchAppID,
+	config.MatchClass,
+	config.MatchInstance,
+	config.MatchProcess,
+	config.MatchCgroup,
+	config.MatchTitle,
 }
 
 // NewIconProvider creates a new IconProvider instance.
-func NewIconProvider(processManager ProcessManager, iconMap AppToIconMap, cache IconCache) *IconProvider {
+func NewIconProvider(processManager ProcessManager, matcher *Matcher, cache IconCache, desktopEntries DesktopEntries) *IconProvider {
 	return &IconProvider{
 		processManager: processManager,
-		iconMap:        iconMap,
+		matcher:        matcher,
 		cache:          cache,
+		desktopEntries: desktopEntries,
 	}
 }
 
-// SetIconMap sets the icon map.
-func (i *IconProvider) SetIconMap(iconMap AppToIconMap) {
+// SetMatcher sets the matcher compiled from the rules and the icon map.
+func (i *IconProvider) SetMatcher(matcher *Matcher) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
-	i.iconMap = iconMap
+	i.matcher = matcher
 }
 
-// AddIcons adds icons to the all windows of all workspaces.
+// AddIcons adds icons to the all windows of all workspaces and the scratchpad,
+// the ignored windows are dropped from the workspaces but kept in the scratchpad.
+// The workspaces get the icons of their outputs as well.
 func (i *IconProvider) AddIcons(workspaces workspace.Workspaces) error {
-	var wg sync.WaitGroup
+	targets := make([]*workspace.Workspace, 0, len(workspaces)+1)
+	for _, ws := range workspaces {
+		targets = append(targets, ws)
+	}
+	// The scratchpad is shared by the workspaces
+	var scratchpad *workspace.Workspace
 	for _, ws := range workspaces {
+		if ws.Scratchpad != nil && scratchpad == nil {
+			scratchpad = ws.Scratchpad
+			targets = append(targets, scratchpad)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, ws := range targets {
 		wg.Add(1)
 		go func(w *workspace.Workspace) {
 			defer wg.Done()
 			slog.Debug("Adding icons to workspace", "workspace", w.String())
+			w.OutputIcon = i.OutputIcon(w.Output)
+			if w != scratchpad {
+				w.Windows = slices.DeleteFunc(w.Windows, i.Ignored)
+			}
 			for _, window := range w.Windows {
-				icon, found := i.GetIcon(window.PID, window.Title)
-				if !found {
-					icon = window.Title
+				icon, _ := i.GetIcon(window)
+				// Hidden by the fallback chain
+				if icon.Icon == "" {
+					continue
 				}
+				icon.Window = window
 				w.AddAppIcon(icon)
 			}
 		}(ws)
@@ -58,52 +95,196 @@ func (i *IconProvider) AddIcons(workspaces workspace.Workspaces) error {
 	return nil
 }
 
+// Ignored reports whether the window is excluded from the workspace name by the ignore rules.
+func (i *IconProvider) Ignored(window workspace.WindowInfo) bool {
+	i.mu.RLock()
+	matcher := i.matcher
+	i.mu.RUnlock()
+	return matcher.Ignored(i.newWindowProperties(window))
+}
+
+// OutputIcon provides the icon for the given output.
+func (i *IconProvider) OutputIcon(output workspace.OutputInfo) string {
+	i.mu.RLock()
+	matcher := i.matcher
+	i.mu.RUnlock()
+	return matcher.MatchOutput(output)
+}
+
 // ClearCache clears the cache.
 func (i *IconProvider) ClearCache() {
 	i.cache.Clear()
 }
 
-// GetIcon provides the icon for the given pid and node name.
-func (i *IconProvider) GetIcon(pid *uint32, name string) (string, bool) {
-	normalizedName := strings.ToLower(name)
-	// Search by name first
-	if icon, ok := i.iconFor(normalizedName); ok {
-		return icon, true
+// GetIcon provides the icon for the given window.
+// Rules are evaluated first in their order and refined by their sub-rules, then the icon map is looked up
+// by the window properties in the legacySources order.
+// A descending rule prefers the icon of the foreground process running in the window.
+// Applications without an explicit rule get the icon of their desktop entry categories.
+// When nothing matches the fallback chain is followed, an empty icon means the window is hidden.
+func (i *IconProvider) GetIcon(window workspace.WindowInfo) (workspace.AppIcon, bool) {
+	i.mu.RLock()
+	matcher := i.matcher
+	i.mu.RUnlock()
+
+	properties := i.newWindowProperties(window)
+	if rule := matcher.MatchRule(properties); rule != nil {
+		if rule.descend {
+			if appIcon, ok := i.foregroundIcon(matcher, window); ok {
+				return appIcon, true
+			}
+		}
+		rule = rule.refine(properties)
+		return rule.appIcon(properties.Get(rule.source)), true
 	}
 
-	// Then search by pid
-	appName, ok := i.processManager.GetProcessName(pid)
-	if !ok {
-		return name, false
+	if icon, ok := i.legacyIconFor(matcher, properties); ok {
+		return workspace.AppIcon{Icon: icon}, true
 	}
 
-	normalizedAppName := strings.ToLower(appName)
-	if icon, ok := i.iconFor(normalizedAppName); ok {
-		return icon, true
+	if entry := properties.DesktopEntry(); entry != nil {
+		if icon, ok := matcher.MatchCategories(entry.Categories); ok {
+			return workspace.AppIcon{Icon: icon}, true
+		}
 	}
+	return workspace.AppIcon{Icon: fallbackIcon(matcher, properties)}, false
+}
 
-	return name, false
+// fallbackIcon returns the value of the first fallback step that has one.
+// Hide and the end of the chain return an empty icon.
+func fallbackIcon(matcher *Matcher, properties *windowProperties) string {
+	for _, step := range matcher.fallback {
+		var icon string
+		switch step {
+		case config.FallbackPlaceholder:
+			icon = matcher.noMatchIcon
+		case config.FallbackName:
+			if entry := properties.DesktopEntry(); entry != nil {
+				icon = entry.Name
+			}
+		case config.FallbackAppID:
+			// xwayland windows have the class instead
+			icon = cmp.Or(properties.Get(config.MatchAppID), properties.Get(config.MatchClass))
+		case config.FallbackProcess:
+			icon = properties.Get(config.MatchProcess)
+		case config.FallbackTitle:
+			icon = properties.Get(config.MatchTitle)
+		case config.FallbackHide:
+			return ""
+		}
+		if icon != "" {
+			return icon
+		}
+	}
+	return ""
 }
 
-func (i *IconProvider) iconFor(name string) (string, bool) {
+// foregroundIcon provides the icon of the foreground process running in the window.
+// Only the process properties of the foreground process are matched and it is not descended any further.
+func (i *IconProvider) foregroundIcon(matcher *Matcher, window workspace.WindowInfo) (workspace.AppIcon, bool) {
+	pid, ok := i.processManager.GetForegroundProcess(window.PID)
+	if !ok || *pid == *window.PID {
+		return workspace.AppIcon{}, false
+	}
+	properties := i.newWindowProperties(workspace.WindowInfo{PID: pid})
+	if rule := matcher.MatchRule(properties); rule != nil {
+		rule = rule.refine(properties)
+		return rule.appIcon(properties.Get(rule.source)), true
+	}
+	icon, ok := i.legacyIconFor(matcher, properties)
+	return workspace.AppIcon{Icon: icon}, ok
+}
+
+// legacyIconFor looks up the icon map by the window properties.
+func (i *IconProvider) legacyIconFor(matcher *Matcher, properties *windowProperties) (string, bool) {
+	for _, source := range legacySources {
+		value := properties.Get(source)
+		if value == "" {
+			continue
+		}
+		if icon, ok := i.iconFor(matcher, strings.ToLower(value)); ok {
+			return icon, true
+		}
+	}
+	return "", false
+}
+
+func (i *IconProvider) iconFor(matcher *Matcher, name string) (string, bool) {
 	if icon, ok := i.cache.GetIcon(name); ok {
 		return icon, true
 	}
 
-	i.mu.RLock()
-	icon, ok := i.iconMap[name]
-	defer i.mu.RUnlock()
-	if ok {
+	if icon, ok := matcher.MatchName(name); ok {
 		i.cache.SetIcon(name, icon)
-		return icon, ok
+		return icon, true
+	}
+	return name, false
+}
+
+// newWindowProperties creates the window properties provider.
+func (i *IconProvider) newWindowProperties(window workspace.WindowInfo) *windowProperties {
+	return &windowProperties{window: window, processManager: i.processManager, desktopEntries: i.desktopEntries}
+}
+
+// windowProperties provides the window properties by the rule match source.
+// The process properties and the desktop entry are resolved on demand only.
+type windowProperties struct {
+	window         workspace.WindowInfo
+	processManager ProcessManager
+	desktopEntries DesktopEntries
+	processName    *string
+	cmdline        *string
+	cgroupAppID    *string
+	desktopEntry   *desktop.Entry
+	desktopLooked  bool
+}
+
+// DesktopEntry returns the desktop entry of the window or nil.
+func (p *windowProperties) DesktopEntry() *desktop.Entry {
+	if !p.desktopLooked {
+		p.desktopEntry, _ = p.desktopEntries.Lookup(p.window.AppID, p.window.Class, p.Get(config.MatchProcess))
+		p.desktopLooked = true
 	}
+	return p.desktopEntry
+}
 
-	// try treat app names in config as a regex and match them against the app name
-	for appName, icon := range i.iconMap {
-		if ok, err := regexp.MatchString(appName, name); err == nil && ok {
-			i.cache.SetIcon(name, icon)
-			return icon, true
+// Get returns the window property for the given match source.
+func (p *windowProperties) Get(source string) string {
+	switch source {
+	case config.MatchTitle:
+		return p.window.Title
+	case config.MatchAppID:
+		return p.window.AppID
+	case config.MatchClass:
+		return p.window.Class
+	case config.MatchInstance:
+		return p.window.Instance
+	case config.MatchProcess:
+		if p.processName == nil {
+			name, _ := p.processManager.GetProcessName(p.window.PID)
+			p.processName = &name
+		}
+		return *p.processName
+	case config.MatchCmdline:
+		if p.cmdline == nil {
+			cmdline, _ := p.processManager.GetProcessCmdline(p.window.PID)
+			p.cmdline = &cmdline
+		}
+		return *p.cmdline
+	case config.MatchCgroup:
+		if p.cgroupAppID == nil {
+			appID, _ := p.processManager.GetProcessAppID(p.window.PID)
+			p.cgroupAppID = &appID
+		}
+		return *p.cgroupAppID
+	case config.MatchDesktop:
+		if entry := p.DesktopEntry(); entry != nil {
+			return entry.ID
+		}
+	case config.MatchFloating:
+		if p.window.Floating {
+			return "true"
 		}
 	}
-	return name, false
+	return ""
 }
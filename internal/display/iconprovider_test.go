@@ -0,0 +1,393 @@
+package display
+
+import (
+	"os"
+	"path/filepath"
+	"sway-icon-to-go/internal/cache"
+	"sway-icon-to-go/internal/config"
+	"sway-icon-to-go/internal/desktop"
+	"sway-icon-to-go/internal/workspace"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockProcessManager struct {
+	names       map[uint32]string
+	cmdlines    map[uint32]string
+	appIDs      map[uint32]string
+	foregrounds map[uint32]uint32
+}
+
+func (m *mockProcessManager) GetProcessName(pid *uint32) (string, bool) {
+	if pid == nil {
+		return "", false
+	}
+	name, ok := m.names[*pid]
+	return name, ok
+}
+
+func (m *mockProcessManager) GetProcessCmdline(pid *uint32) (string, bool) {
+	if pid == nil {
+		return "", false
+	}
+	cmdline, ok := m.cmdlines[*pid]
+	return cmdline, ok
+}
+
+func (m *mockProcessManager) GetProcessAppID(pid *uint32) (string, bool) {
+	if pid == nil {
+		return "", false
+	}
+	appID, ok := m.appIDs[*pid]
+	return appID, ok
+}
+
+func (m *mockProcessManager) GetForegroundProcess(pid *uint32) (*uint32, bool) {
+	if pid == nil {
+		return nil, false
+	}
+	foreground, ok := m.foregrounds[*pid]
+	return &foreground, ok
+}
+
+func TestIconProvider_GetIcon(t *testing.T) {
+	pid := uint32(42)
+	flatpak := uint32(43)
+	processManager := &mockProcessManager{
+		names:  map[uint32]string{pid: "java", flatpak: "bwrap"},
+		appIDs: map[uint32]string{flatpak: "com.spotify.Client"},
+	}
+	iconMap := config.AppToIconMap{
+		"spotify":             "music",
+		"org.mozilla.firefox": "firefox",
+		"jetbrains-idea":      "edit",
+		"java":                "coffee",
+		"mousepad":            "note",
+		".*krusader.*":        "folder",
+	}
+
+	testCases := []struct {
+		name     string
+		window   workspace.WindowInfo
+		expected string
+		found    bool
+	}{
+		{
+			name:     "app_id",
+			window:   workspace.WindowInfo{AppID: "org.mozilla.firefox", Title: "Mousepad"},
+			expected: "firefox",
+			found:    true,
+		},
+		{
+			name:     "class wins over process",
+			window:   workspace.WindowInfo{PID: &pid, Class: "jetbrains-idea", Title: "Mousepad"},
+			expected: "edit",
+			found:    true,
+		},
+		{
+			name:     "process wins over title",
+			window:   workspace.WindowInfo{PID: &pid, Class: "sun-awt-x11", Title: "Mousepad"},
+			expected: "coffee",
+			found:    true,
+		},
+		{
+			name:     "cgroup wins over title",
+			window:   workspace.WindowInfo{PID: &flatpak, Title: "Mousepad"},
+			expected: "music",
+			found:    true,
+		},
+		{
+			name:     "title",
+			window:   workspace.WindowInfo{AppID: "unknown", Title: "Mousepad"},
+			expected: "note",
+			found:    true,
+		},
+		{
+			name:     "regex",
+			window:   workspace.WindowInfo{Class: "Krusader-root"},
+			expected: "folder",
+			found:    true,
+		},
+		{
+			name:     "no match",
+			window:   workspace.WindowInfo{AppID: "unknown", Title: "Untitled"},
+			expected: "Untitled",
+			found:    false,
+		},
+	}
+	matcher, err := NewMatcher(&config.Config{AppToIcon: iconMap, Fallback: config.DefaultFallback()})
+	require.NoError(t, err)
+	for _, testCase := range testCases {
+		provider := NewIconProvider(processManager, matcher, cache.NewCache(), desktop.NewIndex(nil))
+		icon, found := provider.GetIcon(testCase.window)
+		assert.Equal(t, testCase.expected, icon.Icon, testCase.name)
+		assert.Equal(t, testCase.found, found, testCase.name)
+	}
+}
+
+func TestIconProvider_GetIcon_Rules(t *testing.T) {
+	pid := uint32(42)
+	processManager := &mockProcessManager{names: map[uint32]string{pid: "java"}}
+	rules := []config.Rule{
+		{Icon: "edit", Match: config.MatchTitle, Pattern: " - NVIM$"},
+		{Icon: "idea", Match: config.MatchClass, Pattern: "jetbrains-idea"},
+		{Icon: "coffee", Match: config.MatchProcess, Pattern: "java"},
+	}
+	iconMap := config.AppToIconMap{"jetbrains-idea": "legacy"}
+
+	testCases := []struct {
+		name     string
+		window   workspace.WindowInfo
+		expected string
+	}{
+		{
+			name:     "first rule wins",
+			window:   workspace.WindowInfo{PID: &pid, Class: "jetbrains-idea", Title: "main.go - NVIM"},
+			expected: "edit",
+		},
+		{
+			name:     "rules win over the icon map",
+			window:   workspace.WindowInfo{PID: &pid, Class: "JetBrains-IDEA", Title: "Project"},
+			expected: "idea",
+		},
+		{
+			name:     "rule matches the property it is set for only",
+			window:   workspace.WindowInfo{Title: "java"},
+			expected: "java",
+		},
+	}
+	matcher, err := NewMatcher(&config.Config{Rules: rules, AppToIcon: iconMap, Fallback: config.DefaultFallback()})
+	require.NoError(t, err)
+	for _, testCase := range testCases {
+		provider := NewIconProvider(processManager, matcher, cache.NewCache(), desktop.NewIndex(nil))
+		icon, _ := provider.GetIcon(testCase.window)
+		assert.Equal(t, testCase.expected, icon.Icon, testCase.name)
+	}
+}
+
+func TestIconProvider_GetIcon_Cmdline(t *testing.T) {
+	script, profile := uint32(1), uint32(2)
+	processManager := &mockProcessManager{
+		names: map[uint32]string{script: "python3", profile: "firefox"},
+		cmdlines: map[uint32]string{
+			script:  "/usr/bin/python3 /usr/bin/meld",
+			profile: "/usr/lib/firefox/firefox -P work",
+		},
+	}
+	rules := []config.Rule{
+		{Icon: "compare", Match: config.MatchCmdline, Pattern: "python3 .*/meld$"},
+		{Icon: "briefcase", Match: config.MatchCmdline, Pattern: " -P work( |$)"},
+	}
+	matcher, err := NewMatcher(&config.Config{Rules: rules, AppToIcon: config.AppToIconMap{"firefox": "firefox"}})
+	require.NoError(t, err)
+	provider := NewIconProvider(processManager, matcher, cache.NewCache(), desktop.NewIndex(nil))
+
+	icon, _ := provider.GetIcon(workspace.WindowInfo{PID: &script, Title: "Meld"})
+	assert.Equal(t, "compare", icon.Icon)
+	icon, _ = provider.GetIcon(workspace.WindowInfo{PID: &profile, AppID: "firefox"})
+	assert.Equal(t, "briefcase", icon.Icon)
+}
+
+func TestIconProvider_GetIcon_Descend(t *testing.T) {
+	kitty, htop, editor, shell := uint32(1), uint32(2), uint32(3), uint32(4)
+	processManager := &mockProcessManager{
+		names: map[uint32]string{kitty: "kitty", htop: "htop", editor: "nvim", shell: "zsh"},
+		foregrounds: map[uint32]uint32{
+			kitty:  htop,
+			editor: editor,
+		},
+	}
+	rules := []config.Rule{
+		{Icon: "terminal", Match: config.MatchAppID, Pattern: "kitty", Descend: true},
+		{Icon: "chart", Match: config.MatchProcess, Pattern: "htop"},
+	}
+	matcher, err := NewMatcher(&config.Config{Rules: rules, AppToIcon: config.AppToIconMap{"nvim": "edit"}})
+	require.NoError(t, err)
+	provider := NewIconProvider(processManager, matcher, cache.NewCache(), desktop.NewIndex(nil))
+
+	icon, _ := provider.GetIcon(workspace.WindowInfo{PID: &kitty, AppID: "kitty"})
+	assert.Equal(t, "chart", icon.Icon, "foreground process rule")
+
+	processManager.foregrounds[kitty] = editor
+	icon, _ = provider.GetIcon(workspace.WindowInfo{PID: &kitty, AppID: "kitty"})
+	assert.Equal(t, "edit", icon.Icon, "foreground process icon map")
+
+	processManager.foregrounds[kitty] = shell
+	icon, _ = provider.GetIcon(workspace.WindowInfo{PID: &kitty, AppID: "kitty"})
+	assert.Equal(t, "terminal", icon.Icon, "unknown foreground process")
+}
+
+func TestIconProvider_GetIcon_Desktop(t *testing.T) {
+	dir := t.TempDir()
+	entry := "[Desktop Entry]\nType=Application\nName=Mousepad\nExec=mousepad %U\nCategories=Utility;TextEditor;\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "org.xfce.mousepad.desktop"), []byte(entry), 0o644))
+	desktopIndex := desktop.NewIndex([]string{dir})
+	desktopIndex.Load()
+
+	rules := []config.Rule{
+		{Icon: "edit", Match: config.MatchDesktop, Pattern: "org.xfce.mousepad"},
+	}
+	matcher, err := NewMatcher(&config.Config{Rules: rules})
+	require.NoError(t, err)
+	pid := uint32(42)
+	processManager := &mockProcessManager{names: map[uint32]string{pid: "mousepad"}}
+
+	provider := NewIconProvider(processManager, matcher, cache.NewCache(), desktopIndex)
+	icon, found := provider.GetIcon(workspace.WindowInfo{PID: &pid, Title: "Untitled 1 - Mousepad"})
+	assert.True(t, found)
+	assert.Equal(t, "edit", icon.Icon)
+
+	// Unmatched windows get the icon of the desktop entry categories.
+	categoryMatcher, err := NewMatcher(&config.Config{CategoryToIcon: map[string]string{"texteditor": "note"}})
+	require.NoError(t, err)
+	provider.SetMatcher(categoryMatcher)
+	icon, found = provider.GetIcon(workspace.WindowInfo{PID: &pid, Title: "Untitled 1 - Mousepad"})
+	assert.True(t, found)
+	assert.Equal(t, "note", icon.Icon)
+
+	// Otherwise they fall back to the desktop entry name instead of the title.
+	emptyMatcher, err := NewMatcher(&config.Config{Fallback: config.DefaultFallback()})
+	require.NoError(t, err)
+	provider.SetMatcher(emptyMatcher)
+	icon, found = provider.GetIcon(workspace.WindowInfo{PID: &pid, Title: "Untitled 1 - Mousepad"})
+	assert.False(t, found)
+	assert.Equal(t, "Mousepad", icon.Icon)
+}
+
+func TestIconProvider_GetIcon_Label(t *testing.T) {
+	rules := []config.Rule{
+		{Icon: "edit", Match: config.MatchTitle, Pattern: "^(?P<file>[^ ]+) - NVIM$", Label: "{icon} {file}"},
+		{Icon: "terminal", Match: config.MatchTitle, Pattern: `^ssh (?:\w+@)?(?P<host>[\w.-]+)`, Label: "{icon} {host}"},
+	}
+	matcher, err := NewMatcher(&config.Config{Rules: rules})
+	require.NoError(t, err)
+	provider := NewIconProvider(&mockProcessManager{}, matcher, cache.NewCache(), desktop.NewIndex(nil))
+
+	icon, found := provider.GetIcon(workspace.WindowInfo{Title: "main.go - NVIM"})
+	assert.True(t, found)
+	assert.Equal(t, workspace.AppIcon{Icon: "edit", Label: "{icon} {file}", Captures: map[string]string{"file": "main.go"}}, icon)
+
+	icon, _ = provider.GetIcon(workspace.WindowInfo{Title: "ssh root@example.com"})
+	assert.Equal(t, map[string]string{"host": "example.com"}, icon.Captures)
+}
+
+func TestIconProvider_GetIcon_Fallback(t *testing.T) {
+	pid := uint32(42)
+	processManager := &mockProcessManager{names: map[uint32]string{pid: "soffice.bin"}}
+	window := workspace.WindowInfo{PID: &pid, Class: "libreoffice-writer", Title: "secret.odt - LibreOffice Writer"}
+
+	testCases := []struct {
+		name     string
+		fallback []string
+		expected string
+	}{
+		{name: "placeholder", fallback: []string{config.FallbackPlaceholder, config.FallbackTitle}, expected: "question"},
+		{name: "missing desktop entry", fallback: []string{config.FallbackName, config.FallbackProcess}, expected: "soffice.bin"},
+		{name: "class for app_id", fallback: []string{config.FallbackAppID}, expected: "libreoffice-writer"},
+		{name: "title", fallback: []string{config.FallbackTitle}, expected: "secret.odt - LibreOffice Writer"},
+		{name: "hide", fallback: []string{config.FallbackHide, config.FallbackTitle}, expected: ""},
+		{name: "empty chain", fallback: []string{}, expected: ""},
+	}
+	for _, testCase := range testCases {
+		matcher, err := NewMatcher(&config.Config{Fallback: testCase.fallback, NoMatchIcon: "question"})
+		require.NoError(t, err)
+		provider := NewIconProvider(processManager, matcher, cache.NewCache(), desktop.NewIndex(nil))
+		icon, found := provider.GetIcon(window)
+		assert.False(t, found, testCase.name)
+		assert.Equal(t, testCase.expected, icon.Icon, testCase.name)
+	}
+
+	// Hidden windows are left out of the workspace name.
+	matcher, err := NewMatcher(&config.Config{Fallback: []string{config.FallbackHide}})
+	require.NoError(t, err)
+	provider := NewIconProvider(processManager, matcher, cache.NewCache(), desktop.NewIndex(nil))
+	ws := workspace.NewWorkspace("1", 1)
+	ws.AddWindow(window)
+	require.NoError(t, provider.AddIcons(workspace.Workspaces{1: ws}))
+	assert.Empty(t, ws.AppIcons)
+}
+
+func TestIconProvider_AddIcons_Ignore(t *testing.T) {
+	pid := uint32(42)
+	processManager := &mockProcessManager{names: map[uint32]string{pid: "pinentry-gnome3"}}
+	ignore := []config.IgnoreRule{
+		{Match: config.MatchProcess, Pattern: "^pinentry"},
+		{Match: config.MatchTitle, Pattern: "^Picture-in-Picture$"},
+		{Match: config.MatchFloating, Pattern: "true"},
+	}
+	matcher, err := NewMatcher(&config.Config{
+		AppToIcon: config.AppToIconMap{"foot": "terminal"},
+		Ignore:    ignore,
+		Fallback:  config.DefaultFallback(),
+	})
+	require.NoError(t, err)
+	provider := NewIconProvider(processManager, matcher, cache.NewCache(), desktop.NewIndex(nil))
+
+	ws := workspace.NewWorkspace("1", 1)
+	ws.AddWindow(workspace.WindowInfo{AppID: "foot"})
+	ws.AddWindow(workspace.WindowInfo{PID: &pid, Title: "Enter passphrase"})
+	ws.AddWindow(workspace.WindowInfo{AppID: "firefox", Title: "Picture-in-Picture"})
+	ws.AddWindow(workspace.WindowInfo{AppID: "pavucontrol", Floating: true})
+	ws.AddWindow(workspace.WindowInfo{AppID: "foot"})
+	require.NoError(t, provider.AddIcons(workspace.Workspaces{1: ws}))
+
+	foot := workspace.WindowInfo{AppID: "foot"}
+	assert.Equal(t, []workspace.AppIcon{{Icon: "terminal", Window: foot}, {Icon: "terminal", Window: foot}}, ws.AppIcons)
+	assert.Len(t, ws.Windows, 2)
+}
+
+func TestIconProvider_AddIcons_Scratchpad(t *testing.T) {
+	matcher, err := NewMatcher(&config.Config{
+		AppToIcon: config.AppToIconMap{"foot": "terminal", "keepassxc": "key"},
+		Ignore:    []config.IgnoreRule{{Match: config.MatchFloating, Pattern: "true"}},
+		Fallback:  config.DefaultFallback(),
+	})
+	require.NoError(t, err)
+	provider := NewIconProvider(&mockProcessManager{}, matcher, cache.NewCache(), desktop.NewIndex(nil))
+
+	scratchpad := workspace.NewWorkspace("__i3_scratch", -1)
+	scratchpad.AddWindow(workspace.WindowInfo{AppID: "keepassxc", Floating: true})
+	ws1 := workspace.NewWorkspace("1", 1)
+	ws1.AddWindow(workspace.WindowInfo{AppID: "foot"})
+	ws1.AddWindow(workspace.WindowInfo{AppID: "pavucontrol", Floating: true})
+	ws1.Scratchpad = scratchpad
+	ws2 := workspace.NewWorkspace("2", 2)
+	ws2.Scratchpad = scratchpad
+	require.NoError(t, provider.AddIcons(workspace.Workspaces{1: ws1, 2: ws2}))
+
+	assert.Equal(t, []workspace.AppIcon{{Icon: "terminal", Window: workspace.WindowInfo{AppID: "foot"}}}, ws1.AppIcons)
+	assert.Equal(t, []workspace.AppIcon{{Icon: "key", Window: workspace.WindowInfo{AppID: "keepassxc", Floating: true}}}, scratchpad.AppIcons,
+		"the shared scratchpad gets its icons once")
+	assert.Len(t, scratchpad.Windows, 1, "the floating scratchpad window is not ignored")
+	assert.Len(t, ws1.Windows, 1, "the floating workspace window is ignored")
+}
+
+func TestIconProvider_GetIcon_SubRules(t *testing.T) {
+	pid := uint32(42)
+	processManager := &mockProcessManager{names: map[uint32]string{pid: "soffice.bin"}}
+	rules := []config.Rule{
+		{Icon: "paperclip", Match: config.MatchProcess, Pattern: "soffice.bin", Rules: []config.Rule{
+			{Icon: "table", Match: config.MatchTitle, Pattern: "LibreOffice Calc$"},
+			{Icon: "file-word", Match: config.MatchTitle, Pattern: "LibreOffice Writer$", Rules: []config.Rule{
+				{Icon: "envelope", Match: config.MatchTitle, Pattern: "^Mail Merge"},
+			}},
+		}},
+	}
+	matcher, err := NewMatcher(&config.Config{Rules: rules})
+	require.NoError(t, err)
+	provider := NewIconProvider(processManager, matcher, cache.NewCache(), desktop.NewIndex(nil))
+
+	testCases := map[string]string{
+		"budget.ods - LibreOffice Calc":   "table",
+		"letter.odt - LibreOffice Writer": "file-word",
+		"Mail Merge - LibreOffice Writer": "envelope",
+		"LibreOffice Start Center":        "paperclip",
+	}
+	for title, expected := range testCases {
+		icon, found := provider.GetIcon(workspace.WindowInfo{PID: &pid, Title: title})
+		assert.True(t, found, title)
+		assert.Equal(t, expected, icon.Icon, title)
+	}
+}
@@ -1,5 +1,7 @@
 package display
 
+import "sway-icon-to-go/internal/desktop"
+
 // IconCache is an interface that provides a cache of icons.
 type IconCache interface {
 	GetIcon(name string) (string, bool)
@@ -10,4 +12,12 @@ type IconCache interface {
 // ProcessManager is an interface that provides a process manager.
 type ProcessManager interface {
 	GetProcessName(pid *uint32) (string, bool)
+	GetProcessCmdline(pid *uint32) (string, bool)
+	GetProcessAppID(pid *uint32) (string, bool)
+	GetForegroundProcess(pid *uint32) (*uint32, bool)
+}
+
+// DesktopEntries is an interface that looks up the desktop entry of an application.
+type DesktopEntries interface {
+	Lookup(appID string, class string, process string) (*desktop.Entry, bool)
 }
@@ -0,0 +1,290 @@
+package display
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sway-icon-to-go/internal/config"
+	"sway-icon-to-go/internal/workspace"
+)
+
+// Matcher matches windows against the precompiled rules, icon map and categories.
+// It also holds the ignore rules, the fallback chain for the windows nothing matches and the output icons.
+// All the patterns are compiled once and evaluated in a stable order,
+// exact matches are looked up by index.
+type Matcher struct {
+	rules []compiledRule
+	// ignore are the ignore rules, the icon is not set.
+	ignore []compiledRule
+	// ruleIndex maps the match source and the lowercase pattern to the first rule with this pattern.
+	ruleIndex map[ruleKey]int
+	// sources are the match sources used by the rules.
+	sources    []string
+	iconMap    AppToIconMap
+	patterns   []compiledPattern
+	categories map[string]string
+	fallback   []string
+	// noMatchIcon is the placeholder icon of the fallback chain.
+	noMatchIcon string
+	// outputIcons are the output icons, the source is the output property.
+	outputIcons []compiledRule
+}
+
+// ruleKey is the key of the exact match rule index.
+type ruleKey struct {
+	source string
+	value  string
+}
+
+// compiledRule is a rule with the compiled pattern.
+type compiledRule struct {
+	icon    string
+	source  string
+	descend bool
+	label   string
+	style   workspace.Style
+	re      *regexp.Regexp
+	// rules are the sub-rules refining the icon.
+	rules []compiledRule
+}
+
+// compiledPattern is an icon map application name compiled as a regex.
+type compiledPattern struct {
+	icon string
+	re   *regexp.Regexp
+}
+
+// NewMatcher compiles the rules, icon map and categories of the config into a Matcher.
+// Invalid rule patterns are reported as an error. Icon map names are expected to be lowercase,
+// names that are not valid regexes are matched exactly only to keep the existing configs working.
+// Icon map regexes are evaluated longest first so that a more specific name wins.
+func NewMatcher(cfg *config.Config) (*Matcher, error) {
+	iconMap := AppToIconMap(cfg.AppToIcon)
+	m := &Matcher{
+		rules:       make([]compiledRule, 0, len(cfg.Rules)),
+		ruleIndex:   make(map[ruleKey]int, len(cfg.Rules)),
+		iconMap:     iconMap,
+		patterns:    make([]compiledPattern, 0, len(iconMap)),
+		categories:  cfg.CategoryToIcon,
+		fallback:    cfg.Fallback,
+		noMatchIcon: cfg.NoMatchIcon,
+	}
+
+	var errs []error
+	for _, rule := range cfg.Rules {
+		compiled, err := compileRule(rule)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		key := ruleKey{source: rule.Match, value: strings.ToLower(rule.Pattern)}
+		if _, exists := m.ruleIndex[key]; !exists {
+			m.ruleIndex[key] = len(m.rules)
+		}
+		if !slices.Contains(m.sources, rule.Match) {
+			m.sources = append(m.sources, rule.Match)
+		}
+		m.rules = append(m.rules, compiled)
+	}
+	for _, rule := range cfg.Ignore {
+		re, err := regexp.Compile("(?i)" + rule.Pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s ignore rule pattern %q: %w", rule.Match, rule.Pattern, err))
+			continue
+		}
+		m.ignore = append(m.ignore, compiledRule{source: rule.Match, re: re})
+	}
+	for _, outputIcon := range cfg.OutputIcons {
+		re, err := regexp.Compile("(?i)" + outputIcon.Pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s output icon pattern %q: %w", outputIcon.Match, outputIcon.Pattern, err))
+			continue
+		}
+		m.outputIcons = append(m.outputIcons, compiledRule{icon: outputIcon.Icon, source: outputIcon.Match, re: re})
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	names := slices.Sorted(maps.Keys(iconMap))
+	slices.SortStableFunc(names, func(a, b string) int {
+		return cmp.Compare(len(b), len(a))
+	})
+	for _, name := range names {
+		re, err := regexp.Compile(name)
+		if err != nil {
+			slog.Warn("App name is not a valid regex, using exact match only", "name", name, "error", err)
+			continue
+		}
+		m.patterns = append(m.patterns, compiledPattern{icon: iconMap[name], re: re})
+	}
+	return m, nil
+}
+
+// compileRule compiles the rule pattern and the patterns of its sub-rules.
+func compileRule(rule config.Rule) (compiledRule, error) {
+	re, err := regexp.Compile("(?i)" + rule.Pattern)
+	if err != nil {
+		return compiledRule{}, fmt.Errorf("invalid %s rule pattern %q: %w", rule.Match, rule.Pattern, err)
+	}
+	compiled := compiledRule{
+		icon:    rule.Icon,
+		source:  rule.Match,
+		descend: rule.Descend,
+		label:   rule.Label,
+		style:   workspace.Style{Color: rule.Color, Font: rule.Font, Size: rule.Size, Rise: rule.Rise},
+		re:      re,
+	}
+	var errs []error
+	for _, subRule := range rule.Rules {
+		compiledSubRule, err := compileRule(subRule)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		compiled.rules = append(compiled.rules, compiledSubRule)
+	}
+	return compiled, errors.Join(errs...)
+}
+
+// cheapSources are the match sources read from the sway tree,
+// the others need the process properties or the desktop entry.
+var cheapSources = []string{config.MatchTitle, config.MatchAppID, config.MatchClass, config.MatchInstance, config.MatchFloating}
+
+// MatchRule returns the first rule matching the window properties or nil.
+// The costly properties are only resolved once a rule before the exact match needs them.
+func (m *Matcher) MatchRule(properties *windowProperties) *compiledRule {
+	// Find the first rule exactly matching a cheap property, only the rules before it need to be tried one by one.
+	first := len(m.rules)
+	for _, source := range m.sources {
+		if !slices.Contains(cheapSources, source) {
+			continue
+		}
+		value := properties.Get(source)
+		if value == "" {
+			continue
+		}
+		if index, ok := m.ruleIndex[ruleKey{source: source, value: strings.ToLower(value)}]; ok && index < first {
+			first = index
+		}
+	}
+
+	for i, rule := range m.rules[:first] {
+		value := properties.Get(rule.source)
+		if value == "" {
+			continue
+		}
+		if rule.re.MatchString(value) {
+			return &m.rules[i]
+		}
+		// The exact match of the costly properties the index lookup skipped
+		if index, ok := m.ruleIndex[ruleKey{source: rule.source, value: strings.ToLower(value)}]; ok && index == i {
+			return &m.rules[i]
+		}
+	}
+	if first < len(m.rules) {
+		return &m.rules[first]
+	}
+	return nil
+}
+
+// Ignored reports whether the window matches any of the ignore rules.
+func (m *Matcher) Ignored(properties *windowProperties) bool {
+	for _, rule := range m.ignore {
+		if value := properties.Get(rule.source); value != "" && rule.re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchOutput returns the icon of the first output icon matching the output properties, empty when none does.
+func (m *Matcher) MatchOutput(output workspace.OutputInfo) string {
+	for _, outputIcon := range m.outputIcons {
+		var value string
+		switch outputIcon.source {
+		case config.OutputMatchName:
+			value = output.Name
+		case config.OutputMatchMake:
+			value = output.Make
+		case config.OutputMatchModel:
+			value = output.Model
+		case config.OutputMatchSerial:
+			value = output.Serial
+		}
+		if value != "" && outputIcon.re.MatchString(value) {
+			return outputIcon.icon
+		}
+	}
+	return ""
+}
+
+// refine returns the first matching sub-rule refined further or the rule itself.
+func (r *compiledRule) refine(properties *windowProperties) *compiledRule {
+	for i, subRule := range r.rules {
+		if value := properties.Get(subRule.source); value != "" && subRule.re.MatchString(value) {
+			return r.rules[i].refine(properties)
+		}
+	}
+	return r
+}
+
+// appIcon returns the rule icon with the label and the named captures of the matched value.
+func (r *compiledRule) appIcon(value string) workspace.AppIcon {
+	appIcon := workspace.AppIcon{Icon: r.icon, Label: r.label, Style: r.style}
+	if r.label == "" {
+		return appIcon
+	}
+	match := r.re.FindStringSubmatch(value)
+	if match == nil {
+		return appIcon
+	}
+	appIcon.Captures = make(map[string]string)
+	for i, name := range r.re.SubexpNames() {
+		if name != "" {
+			appIcon.Captures[name] = match[i]
+		}
+	}
+	return appIcon
+}
+
+// MatchName returns the icon for the lowercase application name from the icon map.
+func (m *Matcher) MatchName(name string) (string, bool) {
+	if icon, ok := m.iconMap[name]; ok {
+		return icon, true
+	}
+	for _, pattern := range m.patterns {
+		if pattern.re.MatchString(name) {
+			return pattern.icon, true
+		}
+	}
+	return "", false
+}
+
+// mainCategories are the main desktop entry categories as per the XDG menu spec, lowercase.
+var mainCategories = []string{
+	"audiovideo", "audio", "video", "development", "education", "game", "graphics",
+	"network", "office", "science", "settings", "system", "utility",
+}
+
+// MatchCategories returns the icon for the desktop entry categories.
+// The additional categories are more specific, so they are tried before the main ones.
+func (m *Matcher) MatchCategories(categories []string) (string, bool) {
+	for _, main := range []bool{false, true} {
+		for _, category := range categories {
+			category = strings.ToLower(category)
+			if slices.Contains(mainCategories, category) != main {
+				continue
+			}
+			if icon, ok := m.categories[category]; ok {
+				return icon, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,212 @@
+package display
+
+import (
+	"fmt"
+	"regexp"
+	"sway-icon-to-go/internal/cache"
+	"sway-icon-to-go/internal/config"
+	"sway-icon-to-go/internal/desktop"
+	"sway-icon-to-go/internal/workspace"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcher_MatchRule(t *testing.T) {
+	rules := []config.Rule{
+		{Icon: "regex", Match: config.MatchTitle, Pattern: "^main"},
+		{Icon: "exact", Match: config.MatchAppID, Pattern: "Foot"},
+		{Icon: "later", Match: config.MatchTitle, Pattern: "main.go"},
+	}
+	matcher, err := NewMatcher(&config.Config{Rules: rules})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		window   workspace.WindowInfo
+		expected string
+		found    bool
+	}{
+		{
+			name:     "regex before the exact match wins",
+			window:   workspace.WindowInfo{AppID: "foot", Title: "main.go"},
+			expected: "regex",
+			found:    true,
+		},
+		{
+			name:     "exact match is case insensitive",
+			window:   workspace.WindowInfo{AppID: "FOOT", Title: "README.md"},
+			expected: "exact",
+			found:    true,
+		},
+		{
+			name:     "no match",
+			window:   workspace.WindowInfo{AppID: "kitty", Title: "README.md"},
+			expected: "",
+			found:    false,
+		},
+	}
+	for _, testCase := range testCases {
+		provider := NewIconProvider(&mockProcessManager{}, matcher, cache.NewCache(), desktop.NewIndex(nil))
+		rule := matcher.MatchRule(provider.newWindowProperties(testCase.window))
+		assert.Equal(t, testCase.found, rule != nil, testCase.name)
+		if rule != nil {
+			assert.Equal(t, testCase.expected, rule.icon, testCase.name)
+		}
+	}
+}
+
+// countingProcessManager counts the process lookups.
+type countingProcessManager struct {
+	mockProcessManager
+	lookups int
+}
+
+func (m *countingProcessManager) GetProcessName(pid *uint32) (string, bool) {
+	m.lookups++
+	return m.mockProcessManager.GetProcessName(pid)
+}
+
+func (m *countingProcessManager) GetProcessCmdline(pid *uint32) (string, bool) {
+	m.lookups++
+	return m.mockProcessManager.GetProcessCmdline(pid)
+}
+
+func (m *countingProcessManager) GetProcessAppID(pid *uint32) (string, bool) {
+	m.lookups++
+	return m.mockProcessManager.GetProcessAppID(pid)
+}
+
+func TestMatcher_MatchRule_Lazy(t *testing.T) {
+	rules := []config.Rule{
+		{Icon: "title", Match: config.MatchTitle, Pattern: "^main"},
+		{Icon: "process", Match: config.MatchProcess, Pattern: "a+b"},
+		{Icon: "cmdline", Match: config.MatchCmdline, Pattern: "--profile work"},
+	}
+	matcher, err := NewMatcher(&config.Config{Rules: rules})
+	require.NoError(t, err)
+	pid := uint32(42)
+
+	processManager := &countingProcessManager{mockProcessManager: mockProcessManager{names: map[uint32]string{pid: "a+b"}}}
+	provider := NewIconProvider(processManager, matcher, cache.NewCache(), desktop.NewIndex(nil))
+	rule := matcher.MatchRule(provider.newWindowProperties(workspace.WindowInfo{PID: &pid, Title: "main.go"}))
+	require.NotNil(t, rule)
+	assert.Equal(t, "title", rule.icon)
+	assert.Zero(t, processManager.lookups, "the first rule needs no process properties")
+
+	rule = matcher.MatchRule(provider.newWindowProperties(workspace.WindowInfo{PID: &pid, Title: "README.md"}))
+	require.NotNil(t, rule)
+	assert.Equal(t, "process", rule.icon, "exact match of a costly property")
+	assert.Equal(t, 1, processManager.lookups, "the cmdline is not resolved")
+}
+
+func TestMatcher_MatchName(t *testing.T) {
+	iconMap := config.AppToIconMap{
+		"libreoffice":      "paperclip",
+		"libreoffice calc": "table",
+		"c++":              "code",
+	}
+	matcher, err := NewMatcher(&config.Config{AppToIcon: iconMap})
+	require.NoError(t, err)
+
+	// The longest pattern wins on every run no matter the map order.
+	for range 20 {
+		icon, found := matcher.MatchName("libreoffice calc - sheet.ods")
+		assert.True(t, found)
+		assert.Equal(t, "table", icon)
+	}
+
+	// Invalid regex is still matched exactly.
+	icon, found := matcher.MatchName("c++")
+	assert.True(t, found)
+	assert.Equal(t, "code", icon)
+}
+
+func TestNewMatcher_InvalidRule(t *testing.T) {
+	rules := []config.Rule{
+		{Icon: "edit", Match: config.MatchTitle, Pattern: "(unclosed"},
+	}
+	_, err := NewMatcher(&config.Config{Rules: rules})
+	assert.ErrorContains(t, err, "(unclosed")
+}
+
+// benchmarkIconMap generates the icon map with the given number of app names.
+func benchmarkIconMap(size int) AppToIconMap {
+	iconMap := make(AppToIconMap, size)
+	for i := range size {
+		iconMap[fmt.Sprintf("app-%d", i)] = "icon"
+		iconMap[fmt.Sprintf(".*tool-%d.*", i)] = "icon"
+	}
+	return iconMap
+}
+
+func BenchmarkMatcher_MatchName(b *testing.B) {
+	matcher, err := NewMatcher(&config.Config{AppToIcon: config.AppToIconMap(benchmarkIconMap(200))})
+	require.NoError(b, err)
+	b.ResetTimer()
+	for range b.N {
+		matcher.MatchName("unknown window title")
+	}
+}
+
+// BenchmarkMatchString is the baseline compiling every pattern on every lookup.
+func BenchmarkMatchString(b *testing.B) {
+	iconMap := benchmarkIconMap(200)
+	b.ResetTimer()
+	for range b.N {
+		for appName := range iconMap {
+			if ok, err := regexp.MatchString(appName, "unknown window title"); err == nil && ok {
+				break
+			}
+		}
+	}
+}
+
+func TestMatcher_MatchCategories(t *testing.T) {
+	matcher, err := NewMatcher(&config.Config{CategoryToIcon: map[string]string{
+		"development":      "code",
+		"terminalemulator": "terminal",
+		"utility":          "toolbox",
+	}})
+	require.NoError(t, err)
+
+	icon, found := matcher.MatchCategories([]string{"System", "Utility", "TerminalEmulator"})
+	assert.True(t, found)
+	assert.Equal(t, "terminal", icon, "additional category wins over the main ones")
+
+	icon, found = matcher.MatchCategories([]string{"Development", "Utility"})
+	assert.True(t, found)
+	assert.Equal(t, "code", icon, "main categories are tried in order")
+
+	_, found = matcher.MatchCategories([]string{"Game"})
+	assert.False(t, found)
+}
+
+func TestMatcher_MatchOutput(t *testing.T) {
+	matcher, err := NewMatcher(&config.Config{OutputIcons: []config.OutputIcon{
+		{Icon: "laptop", Match: config.OutputMatchName, Pattern: "^eDP-"},
+		{Icon: "L", Match: config.OutputMatchSerial, Pattern: "^0x0000A1B2$"},
+		{Icon: "tv", Match: config.OutputMatchMake, Pattern: "dell"},
+		{Icon: "M", Match: config.OutputMatchModel, Pattern: "U2720Q"},
+	}})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		output   workspace.OutputInfo
+		expected string
+	}{
+		{name: "name", output: workspace.OutputInfo{Name: "eDP-1", Make: "Dell Inc.", Serial: "0x0000A1B2"}, expected: "laptop"},
+		{name: "serial", output: workspace.OutputInfo{Name: "DP-3", Make: "Dell Inc.", Serial: "0x0000A1B2"}, expected: "L"},
+		{name: "make", output: workspace.OutputInfo{Name: "DP-3", Make: "Dell Inc.", Model: "U2720Q"}, expected: "tv"},
+		{name: "model", output: workspace.OutputInfo{Name: "DP-4", Make: "LG", Model: "U2720Q"}, expected: "M"},
+		{name: "no match", output: workspace.OutputInfo{Name: "HDMI-A-1", Make: "LG"}, expected: ""},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, matcher.MatchOutput(tc.output), tc.name)
+	}
+
+	_, err = NewMatcher(&config.Config{OutputIcons: []config.OutputIcon{{Icon: "tv", Match: config.OutputMatchName, Pattern: "(unclosed"}}})
+	assert.ErrorContains(t, err, "(unclosed")
+}
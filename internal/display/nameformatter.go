@@ -1,59 +1,531 @@
 package display
 
 import (
+	"cmp"
 	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sway-icon-to-go/internal/config"
+	"sway-icon-to-go/internal/workspace"
+	"text/template"
+	"unicode/utf8"
 )
 
 // NameFormatter is a struct that formats the workspace name according to the config.
 type NameFormatter struct {
-	format *config.Format
+	format   *config.Format
+	template *template.Template
+}
+
+// templateData is the data the workspace name template is executed with.
+type templateData struct {
+	Number int64
+	Name   string
+	// Icons are the rendered app icons, unique and trimmed as configured, or the single rendered layout.
+	Icons   []string
+	Windows []workspace.WindowInfo
+	Focused bool
+	Output  string
+	// OutputIcon is the icon of the output, empty when none is configured.
+	OutputIcon string
+}
+
+// templateFuncs are the helper functions available in the workspace name template.
+var templateFuncs = template.FuncMap{
+	"join":  func(items []string, sep string) string { return strings.Join(items, sep) },
+	"trunc": truncate,
+	"upper": strings.ToUpper,
+	"pad":   pad,
 }
 
 // NewNameFormatter creates a new NameFormatter with the given config.
-func NewNameFormatter(format *config.Format) *NameFormatter {
-	return &NameFormatter{format: format}
+// The template is built from the delimiter unless it is set, the output icon goes after the number
+// as sway tells the workspace number from the leading digits.
+// An invalid template, keep or sort order and markup are reported as an error.
+func NewNameFormatter(format *config.Format) (*NameFormatter, error) {
+	if format.Markup == config.MarkupPango {
+		format = markupTemplates(format)
+	}
+	text := format.Template
+	if text == "" {
+		text = fmt.Sprintf("{{.Number}}: {{with .OutputIcon}}{{.}} {{end}}{{join .Icons %q}}", format.Delimiter)
+	}
+	if format.Keep != "" && !slices.Contains(config.KeepOrders, format.Keep) {
+		return nil, fmt.Errorf("unknown keep order %q", format.Keep)
+	}
+	if format.Sort != "" && !slices.Contains(config.SortOrders, format.Sort) {
+		return nil, fmt.Errorf("unknown sort order %q", format.Sort)
+	}
+	if format.Markup != "" && !slices.Contains(config.Markups, format.Markup) {
+		return nil, fmt.Errorf("unknown markup %q", format.Markup)
+	}
+	tmpl, err := template.New("workspace").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspace name template %q: %w", text, err)
+	}
+	return &NameFormatter{format: format, template: tmpl}, nil
 }
 
 // Format the workspace name according to the config.
-func (nf *NameFormatter) Format(workspaceNumber int64, appIcons []string) string {
-	if appIcons == nil {
-		return fmt.Sprintf("%d: ", workspaceNumber)
+// The workspace is left as is when the template fails.
+func (nf *NameFormatter) Format(w *workspace.Workspace) string {
+	var renderedAppIcons []string
+	if nf.format.Layout && w.Layout != nil {
+		renderedAppIcons = nf.layoutIcons(w)
+	} else {
+		renderedAppIcons = nf.icons(w)
+	}
+	if badge := nf.scratchpadBadge(w); badge != "" {
+		renderedAppIcons = append(renderedAppIcons, badge)
+	}
+
+	var name strings.Builder
+	err := nf.template.Execute(&name, templateData{
+		Number:     w.Number,
+		Name:       w.Name,
+		Icons:      renderedAppIcons,
+		Windows:    w.Windows,
+		Focused:    w.Focused,
+		Output:     w.Output.Name,
+		OutputIcon: nf.markup(w.OutputIcon, workspace.Style{}),
+	})
+	if err != nil {
+		slog.Error("Error while executing workspace name template", "workspace", w.Name, "error", err)
+		return w.Name
+	}
+	return name.String()
+}
+
+// icons renders the app icons of the workspace sorted, collapsed and limited as configured.
+func (nf *NameFormatter) icons(w *workspace.Workspace) []string {
+	entries := make([]iconEntry, 0, len(w.AppIcons))
+	for _, appIcon := range nf.sort(w.AppIcons) {
+		entries = append(entries, nf.entry(appIcon))
+	}
+
+	if nf.format.Count || nf.format.Uniq {
+		entries = collapse(entries)
+	}
+	entries, overflow := nf.limit(entries)
+
+	renderedAppIcons := make([]string, 0, len(entries)+1)
+	for _, entry := range entries {
+		renderedAppIcons = append(renderedAppIcons, nf.decorateEntry(entry))
+	}
+	if overflow > 0 {
+		renderedAppIcons = append(renderedAppIcons, nf.overflow(overflow))
+	}
+	return renderedAppIcons
+}
+
+// layoutIcons renders the app icons within the container tree of the workspace as a single icon
+// followed by the icons of the windows out of the tree, i.e. the floating ones.
+// The windows beyond MaxIcons in the tree order are replaced with the overflow marker at the end,
+// more windows are dropped the same way until the icons joined by the delimiter fit the LayoutWidth.
+func (nf *NameFormatter) layoutIcons(w *workspace.Workspace) []string {
+	iconByWindow := make(map[int64]string, len(w.AppIcons))
+	for _, appIcon := range w.AppIcons {
+		iconByWindow[appIcon.Window.ID] = nf.decorateEntry(nf.entry(appIcon))
 	}
 
-	if nf.format.Uniq {
-		appIcons = unique(appIcons)
+	limit := len(w.AppIcons)
+	if nf.format.MaxIcons > 0 {
+		limit = min(limit, nf.format.MaxIcons)
+	}
+	renderedAppIcons := nf.renderLayout(w, iconByWindow, limit)
+	for nf.format.LayoutWidth > 0 && limit > 0 &&
+		nf.visibleWidth(strings.Join(renderedAppIcons, nf.format.Delimiter)) > nf.format.LayoutWidth {
+		limit--
+		renderedAppIcons = nf.renderLayout(w, iconByWindow, limit)
 	}
+	return renderedAppIcons
+}
 
-	trimmedAppIcons := []string{}
-	if nf.format.Length > 0 {
-		// Trim app icons to the length specified in the config.
-		for _, appIcon := range appIcons {
-			runes := []rune(appIcon)
-			capLength := min(len(runes), nf.format.Length)
-			trimmedAppIcons = append(trimmedAppIcons, string(runes[:capLength]))
+// renderLayout renders the layout showing at most the limit of windows, the rest are counted by the overflow marker.
+func (nf *NameFormatter) renderLayout(w *workspace.Workspace, iconByWindow map[int64]string, limit int) []string {
+	var shown, overflow int
+	rendered := make(map[int64]bool, len(iconByWindow))
+	show := func(id int64) string {
+		rendered[id] = true
+		if shown >= limit {
+			overflow++
+			return ""
 		}
-	} else {
-		trimmedAppIcons = appIcons
+		shown++
+		return iconByWindow[id]
+	}
+
+	var render func(container *workspace.Container) string
+	render = func(container *workspace.Container) string {
+		if len(container.Children) == 0 {
+			if _, ok := iconByWindow[container.ID]; !ok {
+				// Not a window or an ignored one
+				return ""
+			}
+			return show(container.ID)
+		}
+
+		var children []string
+		for _, child := range container.Children {
+			if rendered := render(child); rendered != "" {
+				children = append(children, rendered)
+			}
+		}
+		if len(children) <= 1 {
+			return strings.Join(children, "")
+		}
+		marker, ok := nf.format.LayoutMarkers[container.Layout]
+		if !ok {
+			marker = "{children}"
+		}
+		return strings.ReplaceAll(marker, "{children}", strings.Join(children, nf.format.Delimiter))
+	}
+
+	var renderedAppIcons []string
+	if layout := render(w.Layout); layout != "" {
+		renderedAppIcons = append(renderedAppIcons, layout)
+	}
+	for _, appIcon := range w.AppIcons {
+		if rendered[appIcon.Window.ID] {
+			continue
+		}
+		if icon := show(appIcon.Window.ID); icon != "" {
+			renderedAppIcons = append(renderedAppIcons, icon)
+		}
+	}
+	if overflow > 0 {
+		renderedAppIcons = append(renderedAppIcons, nf.overflow(overflow))
+	}
+	return renderedAppIcons
+}
+
+// scratchpadBadge renders the badge of the scratchpad windows for the configured workspace, the focused one by default.
+// The badge is empty unless configured or when the scratchpad is empty.
+func (nf *NameFormatter) scratchpadBadge(w *workspace.Workspace) string {
+	if nf.format.Scratchpad == "" || w.Scratchpad == nil || len(w.Scratchpad.AppIcons) == 0 {
+		return ""
+	}
+	if nf.format.ScratchpadWorkspace == 0 && !w.Focused ||
+		nf.format.ScratchpadWorkspace != 0 && w.Number != nf.format.ScratchpadWorkspace {
+		return ""
+	}
+	var icons string
+	if strings.Contains(nf.format.Scratchpad, "{icons}") {
+		icons = strings.Join(nf.icons(w.Scratchpad), nf.format.Delimiter)
+	}
+	return strings.NewReplacer(
+		"{count}", strconv.Itoa(len(w.Scratchpad.AppIcons)),
+		"{icons}", icons,
+	).Replace(nf.format.Scratchpad)
+}
+
+// entry renders the app icon as an entry standing for its window.
+func (nf *NameFormatter) entry(appIcon workspace.AppIcon) iconEntry {
+	return iconEntry{
+		text:       nf.markup(nf.render(appIcon), appIcon.Style),
+		count:      1,
+		focused:    appIcon.Window.Focused,
+		urgent:     appIcon.Window.Urgent,
+		fullscreen: appIcon.Window.FullscreenMode != 0,
+		floating:   appIcon.Window.Floating,
+		sticky:     appIcon.Window.Sticky,
 	}
+}
 
-	return fmt.Sprintf(
-		"%d: %s",
-		workspaceNumber,
-		strings.Join(trimmedAppIcons, nf.format.Delimiter),
-	)
+// decorateEntry adds the count suffix and the decorations of the window states to the entry text.
+func (nf *NameFormatter) decorateEntry(entry iconEntry) string {
+	icon := entry.text
+	if nf.format.Count && entry.count > 1 {
+		icon += nf.countSuffix(entry.count)
+	}
+	if entry.focused {
+		icon = decorate(nf.format.Focused, icon)
+	}
+	if entry.fullscreen {
+		icon = decorate(nf.format.Fullscreen, icon)
+	}
+	if entry.floating {
+		icon = decorate(nf.format.Floating, icon)
+	}
+	if entry.sticky {
+		icon = decorate(nf.format.Sticky, icon)
+	}
+	// Urgency goes last to stand out
+	if entry.urgent {
+		icon = decorate(nf.format.Urgent, icon)
+	}
+	return icon
 }
 
-func unique(slice []string) []string {
-	var uniqueApps []string
+// overflow renders the marker of the dropped icons.
+func (nf *NameFormatter) overflow(count int) string {
+	return strings.ReplaceAll(nf.format.Overflow, "{count}", strconv.Itoa(count))
+}
 
-	seen := make(map[string]struct{})
-	for _, v := range slice {
-		if _, ok := seen[v]; !ok {
-			seen[v] = struct{}{}
-			uniqueApps = append(uniqueApps, v)
+// sort orders the app icons as configured, the floating ones go last when grouped.
+// The equal ones keep the tree order.
+func (nf *NameFormatter) sort(appIcons []workspace.AppIcon) []workspace.AppIcon {
+	var compare func(a, b workspace.AppIcon) int
+	switch nf.format.Sort {
+	case config.SortPosition:
+		compare = func(a, b workspace.AppIcon) int {
+			return cmp.Or(cmp.Compare(a.Window.Rect.X, b.Window.Rect.X), cmp.Compare(a.Window.Rect.Y, b.Window.Rect.Y))
+		}
+	case config.SortAlpha:
+		compare = func(a, b workspace.AppIcon) int {
+			return cmp.Compare(appName(a), appName(b))
+		}
+	case config.SortFocus:
+		compare = func(a, b workspace.AppIcon) int {
+			return cmp.Compare(a.Window.FocusRank, b.Window.FocusRank)
+		}
+	case config.SortPriority:
+		compare = func(a, b workspace.AppIcon) int {
+			return cmp.Compare(nf.format.Priorities[b.Icon], nf.format.Priorities[a.Icon])
 		}
 	}
-	return uniqueApps
+	if nf.format.GroupFloating {
+		byOrder := compare
+		compare = func(a, b workspace.AppIcon) int {
+			floating := cmp.Compare(boolRank(a.Window.Floating), boolRank(b.Window.Floating))
+			if floating != 0 || byOrder == nil {
+				return floating
+			}
+			return byOrder(a, b)
+		}
+	}
+	if compare == nil {
+		return appIcons
+	}
+	sorted := slices.Clone(appIcons)
+	slices.SortStableFunc(sorted, compare)
+	return sorted
+}
+
+// appName returns the lowercase application name of the app icon for the alphabetical order.
+func appName(appIcon workspace.AppIcon) string {
+	return strings.ToLower(cmp.Or(appIcon.Window.AppID, appIcon.Window.Class, appIcon.Icon))
+}
+
+// render renders the app icon label with the captures.
+// The captured text is trimmed to the length specified in the config so the icon is always kept,
+// an app icon without a label is trimmed as a whole.
+func (nf *NameFormatter) render(appIcon workspace.AppIcon) string {
+	if appIcon.Label == "" {
+		return nf.trim(appIcon.Icon)
+	}
+	replacements := []string{"{icon}", appIcon.Icon}
+	for name, value := range appIcon.Captures {
+		replacements = append(replacements, "{"+name+"}", nf.trim(value))
+	}
+	return strings.TrimSpace(strings.NewReplacer(replacements...).Replace(appIcon.Label))
+}
+
+// markupEscaper escapes the text for the Pango markup.
+var markupEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	"'", "&apos;",
+	`"`, "&quot;",
+)
+
+// entityPattern matches a markup entity reference at the start of the text, e.g. "&amp;" or "&#215;".
+var entityPattern = regexp.MustCompile(`^&(#[0-9]+|#x[0-9a-fA-F]+|[a-zA-Z]+);`)
+
+// Patterns of the markup tags and entities taking no or a single character on the screen.
+var (
+	markupTagPattern    = regexp.MustCompile(`<[^>]*>`)
+	markupEntityPattern = regexp.MustCompile(`&(#[0-9]+|#x[0-9a-fA-F]+|[a-zA-Z]+);`)
+)
+
+// visibleWidth returns the width of the text in runes, the markup tags are not counted and the entities
+// are counted as a single character with the pango markup.
+func (nf *NameFormatter) visibleWidth(text string) int {
+	if nf.format.Markup == config.MarkupPango {
+		text = markupEntityPattern.ReplaceAllString(markupTagPattern.ReplaceAllString(text, ""), "&")
+	}
+	return utf8.RuneCountInString(text)
+}
+
+// markupTemplates copies the format with the user templates and the delimiter made safe for the Pango markup.
+// The templates are the markup themselves, only the ampersands not starting an entity are escaped.
+func markupTemplates(format *config.Format) *config.Format {
+	escaped := *format
+	for _, template := range []*string{
+		&escaped.Template, &escaped.Delimiter, &escaped.CountFormat, &escaped.Overflow,
+		&escaped.Focused, &escaped.Urgent, &escaped.Fullscreen, &escaped.Floating, &escaped.Sticky,
+		&escaped.Scratchpad,
+	} {
+		*template = escapeAmpersands(*template)
+	}
+	escaped.LayoutMarkers = make(map[string]string, len(format.LayoutMarkers))
+	for layout, marker := range format.LayoutMarkers {
+		escaped.LayoutMarkers[layout] = escapeAmpersands(marker)
+	}
+	return &escaped
+}
+
+// escapeAmpersands escapes the ampersands of the markup which do not start an entity.
+func escapeAmpersands(text string) string {
+	var escaped strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '&' && !entityPattern.MatchString(text[i:]) {
+			escaped.WriteString("&amp;")
+			continue
+		}
+		escaped.WriteByte(text[i])
+	}
+	return escaped.String()
+}
+
+// markup escapes the text for the Pango markup and wraps it in a span with the style attributes.
+// The attributes are single quoted, their values are escaped.
+// The text is kept as is unless the pango markup is configured.
+func (nf *NameFormatter) markup(text string, style workspace.Style) string {
+	if nf.format.Markup != config.MarkupPango {
+		return text
+	}
+	text = markupEscaper.Replace(text)
+
+	var attrs strings.Builder
+	for _, attr := range []struct{ name, value string }{
+		{"color", style.Color},
+		{"font", style.Font},
+		{"size", style.Size},
+		{"rise", style.Rise},
+	} {
+		if attr.value != "" {
+			fmt.Fprintf(&attrs, " %s='%s'", attr.name, markupEscaper.Replace(attr.value))
+		}
+	}
+	if attrs.Len() == 0 {
+		return text
+	}
+	return "<span" + attrs.String() + ">" + text + "</span>"
+}
+
+// trim trims the text to the length specified in the config.
+func (nf *NameFormatter) trim(text string) string {
+	if nf.format.Length <= 0 {
+		return text
+	}
+	return truncate(nf.format.Length, text)
+}
+
+// truncate truncates the text to the length in runes.
+func truncate(length int, text string) string {
+	runes := []rune(text)
+	return string(runes[:min(len(runes), max(length, 0))])
+}
+
+// pad pads the text with spaces on the right up to the width in runes.
+func pad(width int, text string) string {
+	return text + strings.Repeat(" ", max(width-utf8.RuneCountInString(text), 0))
+}
+
+// iconEntry is a rendered app icon with the number of windows it stands for.
+type iconEntry struct {
+	text       string
+	count      int
+	focused    bool
+	urgent     bool
+	fullscreen bool
+	floating   bool
+	sticky     bool
+}
+
+// collapse collapses the duplicate entries keeping the order of appearance and counting the windows.
+func collapse(entries []iconEntry) []iconEntry {
+	var uniqueEntries []iconEntry
+
+	indexes := make(map[string]int)
+	for _, entry := range entries {
+		if i, ok := indexes[entry.text]; ok {
+			uniqueEntries[i].count += entry.count
+			uniqueEntries[i].focused = uniqueEntries[i].focused || entry.focused
+			uniqueEntries[i].urgent = uniqueEntries[i].urgent || entry.urgent
+			uniqueEntries[i].fullscreen = uniqueEntries[i].fullscreen || entry.fullscreen
+			uniqueEntries[i].floating = uniqueEntries[i].floating || entry.floating
+			uniqueEntries[i].sticky = uniqueEntries[i].sticky || entry.sticky
+			continue
+		}
+		indexes[entry.text] = len(uniqueEntries)
+		uniqueEntries = append(uniqueEntries, entry)
+	}
+	return uniqueEntries
+}
+
+// limit keeps at most the configured number of entries chosen in the keep order and returns
+// the number of the dropped ones. The kept entries stay in their order.
+func (nf *NameFormatter) limit(entries []iconEntry) ([]iconEntry, int) {
+	if nf.format.MaxIcons <= 0 || len(entries) <= nf.format.MaxIcons {
+		return entries, 0
+	}
+
+	frequency := make(map[string]int)
+	for _, entry := range entries {
+		frequency[entry.text] += entry.count
+	}
+	indexes := make([]int, len(entries))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	switch nf.format.Keep {
+	case config.KeepFocused:
+		slices.SortStableFunc(indexes, func(a, b int) int {
+			return cmp.Compare(boolRank(entries[b].focused), boolRank(entries[a].focused))
+		})
+	case config.KeepFrequent:
+		slices.SortStableFunc(indexes, func(a, b int) int {
+			return cmp.Compare(frequency[entries[b].text], frequency[entries[a].text])
+		})
+	}
+
+	kept := indexes[:nf.format.MaxIcons]
+	slices.Sort(kept)
+	limited := make([]iconEntry, 0, len(kept))
+	for _, i := range kept {
+		limited = append(limited, entries[i])
+	}
+	return limited, len(entries) - len(kept)
+}
+
+// decorate renders the decoration template with the icon, the icon is kept as is when the template is empty.
+func decorate(decoration string, icon string) string {
+	if decoration == "" {
+		return icon
+	}
+	return strings.ReplaceAll(decoration, "{icon}", icon)
+}
+
+// boolRank ranks true over false.
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// countSuffix renders the count suffix of the collapsed app icon.
+func (nf *NameFormatter) countSuffix(count int) string {
+	return strings.NewReplacer(
+		"{count}", strconv.Itoa(count),
+		"{superscript}", superscript(count),
+	).Replace(nf.format.CountFormat)
+}
+
+// superscriptDigits are the superscript digits from 0 to 9.
+var superscriptDigits = []rune{'\u2070', '\u00b9', '\u00b2', '\u00b3', '\u2074', '\u2075', '\u2076', '\u2077', '\u2078', '\u2079'}
+
+// superscript writes the number with the superscript digits.
+func superscript(number int) string {
+	digits := []rune(strconv.Itoa(number))
+	for i, digit := range digits {
+		digits[i] = superscriptDigits[digit-'0']
+	}
+	return string(digits)
 }
@@ -2,52 +2,74 @@ package display
 
 import (
 	"sway-icon-to-go/internal/config"
+	"sway-icon-to-go/internal/workspace"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// iconsOf creates the app icons without labels.
+func iconsOf(icons ...string) []workspace.AppIcon {
+	appIcons := make([]workspace.AppIcon, 0, len(icons))
+	for _, icon := range icons {
+		appIcons = append(appIcons, workspace.AppIcon{Icon: icon})
+	}
+	return appIcons
+}
+
 func TestNameFormatter_Format(t *testing.T) {
 	testCases := []struct {
 		name            string
 		format          *config.Format
 		workspaceNumber int64
-		appIcons        []string
+		appIcons        []workspace.AppIcon
 		expected        string
 	}{
 		{
 			name:            "default",
 			format:          config.DefaultFormat(),
 			workspaceNumber: 1,
-			appIcons:        []string{"app1", "app2", "app3"},
+			appIcons:        iconsOf("app1", "app2", "app3"),
 			expected:        "1: app1|app2|app3",
 		},
 		{
 			name:            "uniq",
 			format:          &config.Format{Length: 10, Delimiter: "|", Uniq: true},
 			workspaceNumber: 100,
-			appIcons:        []string{"app1", "app1", "app2"},
+			appIcons:        iconsOf("app1", "app1", "app2"),
 			expected:        "100: app1|app2",
 		},
 		{
 			name:            "length",
 			format:          &config.Format{Length: 10, Delimiter: "+", Uniq: true},
 			workspaceNumber: 1000,
-			appIcons:        []string{"1234567890123", "app2", "app3"},
+			appIcons:        iconsOf("1234567890123", "app2", "app3"),
 			expected:        "1000: 1234567890+app2+app3",
 		},
 		{
 			name:            "delimiter",
 			format:          &config.Format{Length: 10, Delimiter: " ", Uniq: true},
 			workspaceNumber: 10000,
-			appIcons:        []string{"1234567890123", "app2", "app3"},
+			appIcons:        iconsOf("1234567890123", "app2", "app3"),
 			expected:        "10000: 1234567890 app2 app3",
 		},
+		{
+			name:   "label",
+			format: &config.Format{Length: 4, Delimiter: "|", Uniq: true},
+			appIcons: []workspace.AppIcon{
+				{Icon: "\uf044", Label: "{icon} {file}", Captures: map[string]string{"file": "main.go"}},
+				{Icon: "\uf120", Label: "{icon} {host}", Captures: map[string]string{"host": ""}},
+				{Icon: "\uf044", Label: "{icon} {file}", Captures: map[string]string{"file": "main.go"}},
+			},
+			workspaceNumber: 2,
+			expected:        "2: \uf044 main|\uf120",
+		},
 		{
 			name:            "no app icons",
 			format:          &config.Format{Length: 10, Delimiter: " ", Uniq: true},
 			workspaceNumber: 777,
-			appIcons:        []string{},
+			appIcons:        []workspace.AppIcon{},
 			expected:        "777: ",
 		},
 		{
@@ -59,8 +81,441 @@ func TestNameFormatter_Format(t *testing.T) {
 		},
 	}
 	for _, testCase := range testCases {
-		formatter := NewNameFormatter(testCase.format)
-		formatted := formatter.Format(testCase.workspaceNumber, testCase.appIcons)
-		assert.Equal(t, testCase.expected, formatted)
+		formatter, err := NewNameFormatter(testCase.format)
+		require.NoError(t, err)
+		ws := workspace.NewWorkspace("", testCase.workspaceNumber)
+		ws.AppIcons = testCase.appIcons
+		formatted := formatter.Format(ws)
+		assert.Equal(t, testCase.expected, formatted, testCase.name)
+	}
+}
+
+func TestNameFormatter_Format_Template(t *testing.T) {
+	ws := workspace.NewWorkspace("3: old", 3)
+	ws.AppIcons = iconsOf("firefox", "terminal", "terminal")
+	ws.AddWindow(workspace.WindowInfo{AppID: "firefox"})
+	ws.Focused = true
+	ws.Output.Name = "eDP-1"
+
+	testCases := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{name: "no colon", template: `{{.Number}} {{join .Icons " "}}`, expected: "3 firefox terminal"},
+		{name: "helpers", template: `{{.Number}}:{{.Output | upper}}:{{join .Icons "," | trunc 10 | pad 12}}!`, expected: "3:EDP-1:firefox,te  !"},
+		{name: "focus and windows", template: `{{.Number}}{{if .Focused}}*{{end}} {{len .Windows}}`, expected: "3* 1"},
+		{name: "execution error keeps the name", template: `{{index .Icons 5}}`, expected: "3: old"},
+	}
+	for _, testCase := range testCases {
+		formatter, err := NewNameFormatter(&config.Format{Length: -1, Uniq: true, Template: testCase.template})
+		require.NoError(t, err)
+		assert.Equal(t, testCase.expected, formatter.Format(ws), testCase.name)
+	}
+
+	_, err := NewNameFormatter(&config.Format{Template: "{{.Number"})
+	assert.Error(t, err)
+}
+
+func TestNameFormatter_Format_Modes(t *testing.T) {
+	ws := workspace.NewWorkspace("", 1)
+	ws.AppIcons = iconsOf("terminal", "firefox", "terminal", "terminal")
+
+	testCases := []struct {
+		name     string
+		format   *config.Format
+		expected string
+	}{
+		{
+			name:     "repeat",
+			format:   &config.Format{Length: -1, Delimiter: "|"},
+			expected: "1: terminal|firefox|terminal|terminal",
+		},
+		{
+			name:     "uniq",
+			format:   &config.Format{Length: -1, Delimiter: "|", Uniq: true},
+			expected: "1: terminal|firefox",
+		},
+		{
+			name:     "count",
+			format:   &config.Format{Length: -1, Delimiter: "|", Uniq: true, Count: true, CountFormat: config.DefaultCountFormat},
+			expected: "1: terminal\u00d73|firefox",
+		},
+		{
+			name:     "count superscript",
+			format:   &config.Format{Length: -1, Delimiter: "|", Count: true, CountFormat: "{superscript}"},
+			expected: "1: terminal\u00b3|firefox",
+		},
+		{
+			name:     "count suffix template",
+			format:   &config.Format{Length: -1, Delimiter: " ", Count: true, CountFormat: " ({count})"},
+			expected: "1: terminal (3) firefox",
+		},
+	}
+	for _, testCase := range testCases {
+		formatter, err := NewNameFormatter(testCase.format)
+		require.NoError(t, err)
+		assert.Equal(t, testCase.expected, formatter.Format(ws), testCase.name)
+	}
+
+	assert.Equal(t, "\u00b9\u2070\u2075", superscript(105))
+}
+
+func TestNameFormatter_Format_MaxIcons(t *testing.T) {
+	ws := workspace.NewWorkspace("", 1)
+	ws.AppIcons = iconsOf("firefox", "terminal", "chat", "terminal", "music", "terminal")
+	ws.AppIcons[4].Window.Focused = true
+
+	testCases := []struct {
+		name     string
+		format   *config.Format
+		expected string
+	}{
+		{
+			name:     "first",
+			format:   &config.Format{Length: -1, Delimiter: "|", MaxIcons: 2, Overflow: config.DefaultOverflow, Keep: config.KeepFirst},
+			expected: "1: firefox|terminal|+4",
+		},
+		{
+			name:     "focused",
+			format:   &config.Format{Length: -1, Delimiter: "|", Uniq: true, MaxIcons: 2, Overflow: config.DefaultOverflow, Keep: config.KeepFocused},
+			expected: "1: firefox|music|+2",
+		},
+		{
+			name:     "frequent",
+			format:   &config.Format{Length: -1, Delimiter: "|", Count: true, CountFormat: "{count}", MaxIcons: 1, Overflow: "\u2026", Keep: config.KeepFrequent},
+			expected: "1: terminal3|\u2026",
+		},
+		{
+			name:     "within the limit",
+			format:   &config.Format{Length: -1, Delimiter: "|", Uniq: true, MaxIcons: 4, Overflow: config.DefaultOverflow},
+			expected: "1: firefox|terminal|chat|music",
+		},
+	}
+	for _, testCase := range testCases {
+		formatter, err := NewNameFormatter(testCase.format)
+		require.NoError(t, err)
+		assert.Equal(t, testCase.expected, formatter.Format(ws), testCase.name)
+	}
+
+	_, err := NewNameFormatter(&config.Format{Keep: "random"})
+	assert.Error(t, err)
+}
+
+func TestNameFormatter_Format_Sort(t *testing.T) {
+	ws := workspace.NewWorkspace("", 1)
+	ws.AppIcons = []workspace.AppIcon{
+		{Icon: "T", Window: workspace.WindowInfo{AppID: "foot", Rect: workspace.Rect{X: 800}, FocusRank: 1}},
+		{Icon: "F", Window: workspace.WindowInfo{AppID: "firefox", Rect: workspace.Rect{X: 0, Y: 600}, FocusRank: 2}},
+		{Icon: "C", Window: workspace.WindowInfo{Class: "Chromium", Rect: workspace.Rect{X: 0}, FocusRank: 0}},
+		{Icon: "Untitled", Window: workspace.WindowInfo{Rect: workspace.Rect{X: 400}, FocusRank: 3}},
+	}
+
+	testCases := []struct {
+		sort     string
+		expected string
+	}{
+		{sort: config.SortTree, expected: "1: T|F|C|Untitled"},
+		{sort: config.SortPosition, expected: "1: C|F|Untitled|T"},
+		{sort: config.SortAlpha, expected: "1: C|F|T|Untitled"},
+		{sort: config.SortFocus, expected: "1: C|T|F|Untitled"},
+		{sort: config.SortPriority, expected: "1: F|T|C|Untitled"},
+	}
+	for _, testCase := range testCases {
+		formatter, err := NewNameFormatter(&config.Format{
+			Length:     -1,
+			Delimiter:  "|",
+			Sort:       testCase.sort,
+			Priorities: map[string]int{"F": 10, "T": 5},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, testCase.expected, formatter.Format(ws), testCase.sort)
+	}
+
+	_, err := NewNameFormatter(&config.Format{Sort: "random"})
+	assert.Error(t, err)
+}
+
+func TestNameFormatter_Format_Focused(t *testing.T) {
+	ws := workspace.NewWorkspace("", 1)
+	ws.AppIcons = iconsOf("firefox", "terminal", "terminal")
+	ws.AppIcons[2].Window.Focused = true
+
+	formatter, err := NewNameFormatter(&config.Format{Length: -1, Delimiter: "|", Focused: "[{icon}]"})
+	require.NoError(t, err)
+	assert.Equal(t, "1: firefox|terminal|[terminal]", formatter.Format(ws))
+
+	formatter, err = NewNameFormatter(&config.Format{Length: -1, Delimiter: "|", Count: true, CountFormat: "{count}", Focused: "<b>{icon}</b>"})
+	require.NoError(t, err)
+	assert.Equal(t, "1: firefox|<b>terminal2</b>", formatter.Format(ws), "collapsed icon of the focused window")
+
+	formatter, err = NewNameFormatter(&config.Format{Length: -1, Delimiter: "|", Uniq: true})
+	require.NoError(t, err)
+	assert.Equal(t, "1: firefox|terminal", formatter.Format(ws), "no decoration")
+}
+
+func TestNameFormatter_Format_Urgent(t *testing.T) {
+	ws := workspace.NewWorkspace("", 1)
+	ws.AppIcons = iconsOf("slack", "terminal", "terminal")
+	ws.AppIcons[0].Window.Urgent = true
+	ws.AppIcons[2].Window.Urgent = true
+	ws.AppIcons[2].Window.Focused = true
+
+	testCases := []struct {
+		name     string
+		urgent   string
+		expected string
+	}{
+		{name: "marker", urgent: "!{icon}", expected: "1: !slack|!*terminal"},
+		{name: "alternate glyph", urgent: "\uf0f3", expected: "1: \uf0f3|\uf0f3"},
+		{name: "pango", urgent: `<span color="red">{icon}</span>`, expected: `1: <span color="red">slack</span>|<span color="red">*terminal</span>`},
+		{name: "no decoration", urgent: "", expected: "1: slack|*terminal"},
+	}
+	for _, testCase := range testCases {
+		formatter, err := NewNameFormatter(&config.Format{Length: -1, Delimiter: "|", Uniq: true, Focused: "*{icon}", Urgent: testCase.urgent})
+		require.NoError(t, err)
+		assert.Equal(t, testCase.expected, formatter.Format(ws), testCase.name)
+	}
+
+	// The double quoted attributes reach sway escaped in the rename command.
+	formatter, err := NewNameFormatter(&config.Format{
+		Length:    -1,
+		Delimiter: "|",
+		Uniq:      true,
+		Focused:   "*{icon}",
+		Urgent:    `<span color="red">{icon}</span>`,
+		Markup:    config.MarkupPango,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `rename workspace " " to "1: <span color=\"red\">slack</span>|<span color=\"red\">*terminal</span>"`,
+		ws.ToRenameCommand(formatter))
+
+	// The marker clears with the urgency hint.
+	ws.AppIcons[0].Window.Urgent = false
+	ws.AppIcons[2].Window.Urgent = false
+	formatter, err = NewNameFormatter(&config.Format{Length: -1, Delimiter: "|", Uniq: true, Urgent: "!{icon}"})
+	require.NoError(t, err)
+	assert.Equal(t, "1: slack|terminal", formatter.Format(ws))
+}
+
+func TestNameFormatter_Format_Pango(t *testing.T) {
+	ws := workspace.NewWorkspace("", 1)
+	ws.AppIcons = []workspace.AppIcon{
+		{Icon: "\uf269", Style: workspace.Style{Color: "#ff7139", Size: "large"}},
+		{Icon: "\uf044", Label: "{icon} {file}", Captures: map[string]string{"file": "a&b<c>.go"}, Style: workspace.Style{Font: "Font Awesome 6 Free"}},
+		{Icon: `Tom's "notes"`},
+		{Icon: `Tom's "notes"`, Window: workspace.WindowInfo{Focused: true}},
+	}
+
+	// The templates and the delimiter are markup, only their ampersands not starting an entity are escaped
+	formatter, err := NewNameFormatter(&config.Format{
+		Length:      -1,
+		Delimiter:   " & ",
+		Count:       true,
+		CountFormat: "<sup>&#215;{count}</sup>",
+		Focused:     "<b>{icon}</b>",
+		Markup:      config.MarkupPango,
+	})
+	require.NoError(t, err)
+	assert.Equal(t,
+		"1: <span color='#ff7139' size='large'>\uf269</span> &amp; "+
+			"<span font='Font Awesome 6 Free'>\uf044 a&amp;b&lt;c&gt;.go</span> &amp; "+
+			"<b>Tom&apos;s &quot;notes&quot;<sup>&#215;2</sup></b>",
+		formatter.Format(ws))
+
+	formatter, err = NewNameFormatter(&config.Format{
+		Length:    -1,
+		Delimiter: "|",
+		MaxIcons:  1,
+		Overflow:  "<i>&+{count}</i>",
+		Markup:    config.MarkupPango,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "1: <span color='#ff7139' size='large'>\uf269</span>|<i>&amp;+3</i>", formatter.Format(ws), "overflow")
+
+	formatter, err = NewNameFormatter(&config.Format{Length: -1, Delimiter: " ", Markup: config.MarkupNone})
+	require.NoError(t, err)
+	assert.Equal(t, "1: \uf269 \uf044 a&b<c>.go Tom's \"notes\" Tom's \"notes\"", formatter.Format(ws), "plain text")
+
+	_, err = NewNameFormatter(&config.Format{Markup: "html"})
+	assert.Error(t, err)
+}
+
+func TestNameFormatter_Format_States(t *testing.T) {
+	ws := workspace.NewWorkspace("", 1)
+	ws.AppIcons = []workspace.AppIcon{
+		{Icon: "mpv", Window: workspace.WindowInfo{FullscreenMode: 1, Rect: workspace.Rect{X: 100}}},
+		{Icon: "calc", Window: workspace.WindowInfo{Floating: true, Rect: workspace.Rect{X: 0}}},
+		{Icon: "pip", Window: workspace.WindowInfo{Floating: true, Sticky: true, Rect: workspace.Rect{X: 50}}},
+		{Icon: "term", Window: workspace.WindowInfo{Rect: workspace.Rect{X: 200}}},
+	}
+	format := &config.Format{
+		Length:     -1,
+		Delimiter:  "|",
+		Fullscreen: "{icon}F",
+		Floating:   "~{icon}",
+		Sticky:     "{icon}^",
+	}
+
+	formatter, err := NewNameFormatter(format)
+	require.NoError(t, err)
+	assert.Equal(t, "1: mpvF|~calc|~pip^|term", formatter.Format(ws))
+
+	format.GroupFloating = true
+	formatter, err = NewNameFormatter(format)
+	require.NoError(t, err)
+	assert.Equal(t, "1: mpvF|term|~calc|~pip^", formatter.Format(ws), "floating after tiled")
+
+	format.Sort = config.SortPosition
+	formatter, err = NewNameFormatter(format)
+	require.NoError(t, err)
+	assert.Equal(t, "1: mpvF|term|~calc|~pip^", formatter.Format(ws), "sorted within the groups")
+}
+
+func TestNameFormatter_Format_Layout(t *testing.T) {
+	// splith(firefox, tabbed(a, b, c), splitv(term)) with an ignored window in the split and a floating calc
+	ws := workspace.NewWorkspace("", 1)
+	ws.Layout = &workspace.Container{ID: 1, Layout: "splith", Children: []*workspace.Container{
+		{ID: 10},
+		{ID: 2, Layout: "tabbed", Children: []*workspace.Container{{ID: 11}, {ID: 12}, {ID: 13}}},
+		{ID: 3, Layout: "splitv", Children: []*workspace.Container{{ID: 14}, {ID: 15}}},
+	}}
+	ws.AppIcons = []workspace.AppIcon{
+		{Icon: "firefox", Window: workspace.WindowInfo{ID: 10}},
+		{Icon: "a", Window: workspace.WindowInfo{ID: 11}},
+		{Icon: "b", Window: workspace.WindowInfo{ID: 12, Focused: true}},
+		{Icon: "a", Window: workspace.WindowInfo{ID: 13}},
+		{Icon: "term", Window: workspace.WindowInfo{ID: 14}},
+		{Icon: "calc", Window: workspace.WindowInfo{ID: 16, Floating: true}},
+	}
+
+	testCases := []struct {
+		name     string
+		format   *config.Format
+		expected string
+	}{
+		{
+			name:     "default markers",
+			format:   &config.Format{Length: -1, Delimiter: "|", Uniq: true, Layout: true, LayoutMarkers: config.DefaultLayoutMarkers()},
+			expected: "1: [firefox|T(a|b|a)|term]|calc",
+		},
+		{
+			name: "custom markers",
+			format: &config.Format{Length: 2, Delimiter: " ", Focused: "*{icon}", Layout: true, LayoutMarkers: map[string]string{
+				"tabbed": "<{children}>",
+			}},
+			expected: "1: fi <a *b a> te ca",
+		},
+		{
+			name:     "max icons",
+			format:   &config.Format{Length: -1, Delimiter: "|", MaxIcons: 3, Overflow: "+{count}", Layout: true, LayoutMarkers: config.DefaultLayoutMarkers()},
+			expected: "1: [firefox|T(a|b)]|+3",
+		},
+		{
+			name:     "width",
+			format:   &config.Format{Length: -1, Delimiter: "|", LayoutWidth: 20, Overflow: "+{count}", Layout: true, LayoutMarkers: config.DefaultLayoutMarkers()},
+			expected: "1: [firefox|T(a|b)]|+3",
+		},
+		{
+			name: "width without markup",
+			format: &config.Format{Length: -1, Delimiter: "|", LayoutWidth: 20, Overflow: "+{count}", Markup: config.MarkupPango, Layout: true, LayoutMarkers: map[string]string{
+				"splith": "[{children}]",
+				"tabbed": "<b>T</b>({children})",
+			}},
+			expected: "1: [firefox|<b>T</b>(a|b)]|+3",
+		},
+		{
+			name:     "flat",
+			format:   &config.Format{Length: -1, Delimiter: "|", Uniq: true, LayoutMarkers: config.DefaultLayoutMarkers()},
+			expected: "1: firefox|a|b|term|calc",
+		},
 	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			formatter, err := NewNameFormatter(tc.format)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, formatter.Format(ws))
+		})
+	}
+}
+
+func TestNameFormatter_Format_Scratchpad(t *testing.T) {
+	scratchpad := workspace.NewWorkspace("__i3_scratch", -1)
+	scratchpad.AppIcons = iconsOf("key", "music", "key")
+	ws1 := workspace.NewWorkspace("", 1)
+	ws1.AppIcons = iconsOf("term")
+	ws2 := workspace.NewWorkspace("", 2)
+	ws2.Focused = true
+
+	testCases := []struct {
+		name       string
+		format     *config.Format
+		expected1  string
+		expected2  string
+		scratchpad *workspace.Workspace
+	}{
+		{
+			name:       "count on the focused workspace",
+			format:     &config.Format{Length: -1, Delimiter: "|", Uniq: true, Scratchpad: "S{count}"},
+			expected1:  "1: term",
+			expected2:  "2: S3",
+			scratchpad: scratchpad,
+		},
+		{
+			name:       "icons on the chosen workspace",
+			format:     &config.Format{Length: -1, Delimiter: "|", Uniq: true, Scratchpad: "S({icons})", ScratchpadWorkspace: 1},
+			expected1:  "1: term|S(key|music)",
+			expected2:  "2: ",
+			scratchpad: scratchpad,
+		},
+		{
+			name:       "empty scratchpad",
+			format:     &config.Format{Length: -1, Delimiter: "|", Uniq: true, Scratchpad: "S{count}"},
+			expected1:  "1: term",
+			expected2:  "2: ",
+			scratchpad: workspace.NewWorkspace("__i3_scratch", -1),
+		},
+		{
+			name:       "disabled",
+			format:     &config.Format{Length: -1, Delimiter: "|", Uniq: true},
+			expected1:  "1: term",
+			expected2:  "2: ",
+			scratchpad: scratchpad,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ws1.Scratchpad = tc.scratchpad
+			ws2.Scratchpad = tc.scratchpad
+			formatter, err := NewNameFormatter(tc.format)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected1, formatter.Format(ws1))
+			assert.Equal(t, tc.expected2, formatter.Format(ws2))
+		})
+	}
+}
+
+func TestNameFormatter_Format_OutputIcon(t *testing.T) {
+	ws := workspace.NewWorkspace("", 1)
+	ws.AppIcons = iconsOf("firefox", "term")
+	ws.OutputIcon = "<L>"
+
+	formatter, err := NewNameFormatter(config.DefaultFormat())
+	require.NoError(t, err)
+	assert.Equal(t, "1: <L> firefox|term", formatter.Format(ws), "after the number")
+
+	formatter, err = NewNameFormatter(&config.Format{Length: -1, Delimiter: " ", Markup: config.MarkupPango})
+	require.NoError(t, err)
+	assert.Equal(t, "1: &lt;L&gt; firefox term", formatter.Format(ws), "escaped")
+
+	formatter, err = NewNameFormatter(&config.Format{Length: -1, Template: "{{.Number}}{{.OutputIcon}}"})
+	require.NoError(t, err)
+	assert.Equal(t, "1<L>", formatter.Format(ws), "template")
+
+	ws.OutputIcon = ""
+	formatter, err = NewNameFormatter(config.DefaultFormat())
+	require.NoError(t, err)
+	assert.Equal(t, "1: firefox|term", formatter.Format(ws), "no output icon")
 }
@@ -0,0 +1,50 @@
+package display
+
+import (
+	"fmt"
+	"path"
+	"sway-icon-to-go/internal/config"
+	"sway-icon-to-go/internal/workspace"
+)
+
+// OutputNameFormatter formats the workspace name with the format of the output the workspace is on.
+type OutputNameFormatter struct {
+	nameFormatter *NameFormatter
+	outputs       []outputNameFormatter
+}
+
+// outputNameFormatter is the name formatter of the outputs matching the glob.
+type outputNameFormatter struct {
+	match         string
+	nameFormatter *NameFormatter
+}
+
+// NewOutputNameFormatter creates a new OutputNameFormatter with the format and the output formats of the config.
+// An invalid format of any output is reported as an error.
+func NewOutputNameFormatter(cfg *config.Config) (*OutputNameFormatter, error) {
+	nameFormatter, err := NewNameFormatter(cfg.Format)
+	if err != nil {
+		return nil, err
+	}
+	outputs := make([]outputNameFormatter, 0, len(cfg.Outputs))
+	for _, output := range cfg.Outputs {
+		outputFormatter, err := NewNameFormatter(output.Format)
+		if err != nil {
+			return nil, fmt.Errorf("output %q: %w", output.Match, err)
+		}
+		outputs = append(outputs, outputNameFormatter{match: output.Match, nameFormatter: outputFormatter})
+	}
+	return &OutputNameFormatter{nameFormatter: nameFormatter, outputs: outputs}, nil
+}
+
+// Format the workspace name with the format of the first output glob matching the workspace output.
+// The workspaces on the other outputs get the default format.
+func (of *OutputNameFormatter) Format(w *workspace.Workspace) string {
+	for _, output := range of.outputs {
+		// The globs are validated on load
+		if ok, _ := path.Match(output.match, w.Output.Name); ok {
+			return output.nameFormatter.Format(w)
+		}
+	}
+	return of.nameFormatter.Format(w)
+}
@@ -0,0 +1,51 @@
+package display
+
+import (
+	"sway-icon-to-go/internal/config"
+	"sway-icon-to-go/internal/workspace"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputNameFormatter_Format(t *testing.T) {
+	cfg := &config.Config{
+		Format: &config.Format{Length: -1, Delimiter: "|", Uniq: true},
+		Outputs: []config.OutputFormat{
+			{Match: "eDP-*", Format: &config.Format{Length: 2, Delimiter: "", Uniq: true}},
+			{Match: "DP-3", Format: &config.Format{Length: -1, Delimiter: " ", Template: "{{.Number}} {{join .Icons .Output}}"}},
+			{Match: "*", Format: &config.Format{Length: -1, Delimiter: "+"}},
+		},
+	}
+	formatter, err := NewOutputNameFormatter(cfg)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		output   string
+		expected string
+	}{
+		{output: "eDP-1", expected: "1: fite"},
+		{output: "DP-3", expected: "1 firefoxDP-3term"},
+		{output: "HDMI-A-1", expected: "1: firefox+term"},
+		{output: "", expected: "1: firefox+term"},
+	}
+	for _, tc := range testCases {
+		ws := workspace.NewWorkspace("", 1)
+		ws.Output.Name = tc.output
+		ws.AppIcons = iconsOf("firefox", "term")
+		assert.Equal(t, tc.expected, formatter.Format(ws), tc.output)
+	}
+
+	cfg.Outputs = cfg.Outputs[:1]
+	formatter, err = NewOutputNameFormatter(cfg)
+	require.NoError(t, err)
+	ws := workspace.NewWorkspace("", 1)
+	ws.Output.Name = "DP-3"
+	ws.AppIcons = iconsOf("firefox", "term")
+	assert.Equal(t, "1: firefox|term", formatter.Format(ws), "default format")
+
+	cfg.Outputs = []config.OutputFormat{{Match: "DP-3", Format: &config.Format{Sort: "random"}}}
+	_, err = NewOutputNameFormatter(cfg)
+	assert.Error(t, err)
+}
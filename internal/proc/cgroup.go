@@ -0,0 +1,77 @@
+package proc
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// flatpakScope matches Flatpak scopes: app-flatpak-<app id>-<pid>.scope
+	flatpakScope = regexp.MustCompile(`^app-flatpak-(.+)-[0-9]+\.scope$`)
+	// snapScope matches Snap scopes and services: snap.<name>.<app>-<uuid>.scope, snap.<name>.<app>.service
+	snapScope = regexp.MustCompile(`^snap\.([^.]+)\.`)
+	// appUnit matches the systemd app units as per XDG spec:
+	// app[-<launcher>]-<app id>-<random>.scope or app[-<launcher>]-<app id>[@<random>].service
+	appUnit = regexp.MustCompile(`^app-(.+?)(?:-[0-9a-fA-F]+\.scope|(?:@[0-9a-zA-Z]*)?\.service)$`)
+)
+
+// appUnitLaunchers are the launcher prefixes of the systemd app unit names.
+var appUnitLaunchers = []string{"gnome", "kde", "sway", "hyprland", "niri", "river", "wayfire", "labwc", "uwsm"}
+
+// ResolveAppID resolves the application ID from the process cgroup,
+// e.g. "org.mozilla.firefox" for Flatpak, "snap.spotify" for Snap or the systemd app scope ID.
+// An empty ID is returned when the process does not belong to an application unit.
+func (l *LinuxResolver) ResolveAppID(pid uint32) (string, error) {
+	file, err := os.Open(filepath.Join(l.pidPath(pid), "cgroup"))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if appID := appIDFromCgroup(fields[2]); appID != "" {
+			return appID, nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// appIDFromCgroup derives the application ID from the innermost application unit of the cgroup path.
+func appIDFromCgroup(cgroup string) string {
+	units := strings.Split(path.Clean(cgroup), "/")
+	for i := len(units) - 1; i >= 0; i-- {
+		unit := units[i]
+		if match := flatpakScope.FindStringSubmatch(unit); match != nil {
+			return unescapeUnitName(match[1])
+		}
+		if match := snapScope.FindStringSubmatch(unit); match != nil {
+			return "snap." + match[1]
+		}
+		if match := appUnit.FindStringSubmatch(unit); match != nil {
+			appID := match[1]
+			for _, launcher := range appUnitLaunchers {
+				if trimmed, ok := strings.CutPrefix(appID, launcher+"-"); ok {
+					appID = trimmed
+					break
+				}
+			}
+			return unescapeUnitName(appID)
+		}
+	}
+	return ""
+}
+
+// unescapeUnitName reverts the systemd unit name escaping of dashes.
+func unescapeUnitName(name string) string {
+	return strings.ReplaceAll(name, `\x2d`, "-")
+}
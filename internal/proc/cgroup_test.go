@@ -0,0 +1,49 @@
+package proc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAppIDFromCgroup(t *testing.T) {
+	tests := []struct {
+		cgroup   string
+		expected string
+	}{
+		{"/user.slice/user-1000.slice/user@1000.service/app.slice/app-flatpak-org.mozilla.firefox-12345.scope", "org.mozilla.firefox"},
+		{"/user.slice/user-1000.slice/user@1000.service/app.slice/snap.spotify.spotify-0b1d5a6e-8f4e-4b4c-9a0b-4b2f1c7d2a11.scope", "snap.spotify"},
+		{"/user.slice/user-1000.slice/user@1000.service/app.slice/app-sway-foot-a1b2c3.scope", "foot"},
+		{"/user.slice/user-1000.slice/user@1000.service/app.slice/app-gnome-org.gnome.Nautilus-4242.scope", "org.gnome.Nautilus"},
+		{"/user.slice/user-1000.slice/user@1000.service/app.slice/app-org.kde.dolphin@0a1b2c.service", "org.kde.dolphin"},
+		{"/user.slice/user-1000.slice/user@1000.service/app.slice/app-uwsm-my\\x2dtool-1234.scope", "my-tool"},
+		{"/user.slice/user-1000.slice/session-2.scope", ""},
+		{"/", ""},
+	}
+	for _, test := range tests {
+		if appID := appIDFromCgroup(test.cgroup); appID != test.expected {
+			t.Errorf("Expected %q for %s, got %q", test.expected, test.cgroup, appID)
+		}
+	}
+}
+
+func TestLinuxResolver_ResolveAppID(t *testing.T) {
+	procPath := t.TempDir()
+	pidPath := filepath.Join(procPath, "100")
+	if err := os.MkdirAll(pidPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cgroup := "1:name=systemd:/user.slice\n0::/user.slice/user-1000.slice/user@1000.service/app.slice/app-flatpak-org.telegram.desktop-777.scope\n"
+	if err := os.WriteFile(filepath.Join(pidPath, "cgroup"), []byte(cgroup), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resolver := &LinuxResolver{ProcPath: procPath}
+
+	appID, err := resolver.ResolveAppID(100)
+	if err != nil || appID != "org.telegram.desktop" {
+		t.Errorf("Expected org.telegram.desktop, got %q (%v)", appID, err)
+	}
+	if _, err := resolver.ResolveAppID(200); err == nil {
+		t.Errorf("Expected error for missing process")
+	}
+}
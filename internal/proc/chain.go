@@ -0,0 +1,194 @@
+package proc
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Unwrapper extracts the real application name from the launcher command line.
+type Unwrapper interface {
+	// Unwrap returns the application name for the launcher arguments
+	// or false if the application can not be told.
+	Unwrap(argv []string) (string, bool)
+}
+
+// UnwrapperFunc is a function implementing the Unwrapper interface.
+type UnwrapperFunc func(argv []string) (string, bool)
+
+// Unwrap calls f(argv).
+func (f UnwrapperFunc) Unwrap(argv []string) (string, bool) {
+	return f(argv)
+}
+
+// Unwrappers are the built-in unwrappers by name.
+var Unwrappers = map[string]Unwrapper{
+	"java":     UnwrapperFunc(unwrapJava),
+	"script":   UnwrapperFunc(unwrapScript),
+	"wine":     UnwrapperFunc(unwrapWine),
+	"electron": UnwrapperFunc(unwrapElectron),
+}
+
+// Launcher binds an unwrapper to the executable names of the launcher.
+type Launcher struct {
+	exe       *regexp.Regexp
+	unwrapper Unwrapper
+}
+
+// NewLauncher creates a launcher for the built-in unwrapper and the executable name patterns.
+// The patterns are regexes matching the whole executable name.
+func NewLauncher(unwrap string, exePatterns []string) (Launcher, error) {
+	unwrapper, ok := Unwrappers[unwrap]
+	if !ok {
+		return Launcher{}, fmt.Errorf("unknown launcher unwrapper %q", unwrap)
+	}
+	exe, err := regexp.Compile("^(?:" + strings.Join(exePatterns, "|") + ")$")
+	if err != nil {
+		return Launcher{}, fmt.Errorf("invalid %s launcher executable pattern: %w", unwrap, err)
+	}
+	return Launcher{exe: exe, unwrapper: unwrapper}, nil
+}
+
+// ChainResolver resolves the process name unwrapping the well-known launchers to the real application name.
+// Launchers are tried in order, the executable name is used when none of them can tell the application.
+// It embeds LinuxResolver so the other process properties are resolved as usual.
+type ChainResolver struct {
+	*LinuxResolver
+	Launchers []Launcher
+}
+
+// NewChainResolver creates a new ChainResolver instance.
+func NewChainResolver(resolver *LinuxResolver, launchers []Launcher) *ChainResolver {
+	return &ChainResolver{LinuxResolver: resolver, Launchers: launchers}
+}
+
+// Resolve resolves the application name by pid.
+func (c *ChainResolver) Resolve(pid uint32) (string, error) {
+	name, err := c.LinuxResolver.Resolve(pid)
+	if err != nil {
+		return "", err
+	}
+
+	var argv []string
+	argvRead := false
+	for _, launcher := range c.Launchers {
+		if !launcher.exe.MatchString(name) {
+			continue
+		}
+		if !argvRead {
+			if argv, err = c.argv(pid); err != nil {
+				// The executable name is still good enough.
+				return name, nil
+			}
+			argvRead = true
+		}
+		if app, ok := launcher.unwrapper.Unwrap(argv); ok {
+			return app, nil
+		}
+	}
+	return name, nil
+}
+
+// javaValueOptions are the java options followed by a separate value.
+var javaValueOptions = []string{
+	"-cp", "-classpath", "--class-path",
+	"-p", "--module-path", "--upgrade-module-path",
+	"--add-modules", "--add-opens", "--add-exports", "--add-reads",
+	"--limit-modules", "--patch-module",
+}
+
+// scriptValueOptions are the options followed by a separate value by the interpreter name.
+var scriptValueOptions = map[string][]string{
+	"python": {"-W", "-X", "--check-hash-based-pycs"},
+	"node": {
+		"-r", "--require", "--import", "--loader", "--experimental-loader",
+		"-C", "--conditions", "--title",
+	},
+	"ruby": {"-I", "-r", "-E", "-C"},
+	"perl": {"-I"},
+}
+
+// unwrapJava returns the jar file name or the main class.
+func unwrapJava(argv []string) (string, bool) {
+	for i := 1; i < len(argv); i++ {
+		arg := argv[i]
+		switch {
+		case (arg == "-jar" || arg == "-m" || arg == "--module") && i+1 < len(argv):
+			return strings.TrimSuffix(filepath.Base(argv[i+1]), ".jar"), true
+		case slices.Contains(javaValueOptions, arg):
+			i++
+		case strings.HasPrefix(arg, "-"):
+		default:
+			return arg, true
+		}
+	}
+	return "", false
+}
+
+// unwrapScript returns the script name for python, node, ruby, perl and alike.
+func unwrapScript(argv []string) (string, bool) {
+	if len(argv) == 0 {
+		return "", false
+	}
+	valueOptions := scriptValueOptions[scriptInterpreter(argv[0])]
+	for i := 1; i < len(argv); i++ {
+		arg := argv[i]
+		switch {
+		case arg == "-m" && i+1 < len(argv):
+			// python -m module
+			return argv[i+1], true
+		case arg == "-c" || arg == "-e":
+			// inline code, nothing to tell
+			return "", false
+		case slices.Contains(valueOptions, arg):
+			i++
+		case strings.HasPrefix(arg, "-"):
+		default:
+			return strings.TrimSuffix(filepath.Base(arg), filepath.Ext(arg)), true
+		}
+	}
+	return "", false
+}
+
+// scriptInterpreter returns the interpreter name of the executable without the path and the version, e.g. python for python3.12.
+func scriptInterpreter(exe string) string {
+	name := strings.TrimRight(filepath.Base(exe), "0123456789.")
+	// Debian names node nodejs
+	return strings.TrimSuffix(name, "js")
+}
+
+// unwrapWine returns the Windows executable name.
+// Wine usually rewrites argv[0] to the Windows path so it is checked as well.
+func unwrapWine(argv []string) (string, bool) {
+	for _, arg := range argv {
+		if strings.HasSuffix(strings.ToLower(arg), ".exe") {
+			// Windows paths use backslashes
+			return filepath.Base(strings.ReplaceAll(arg, `\`, "/")), true
+		}
+	}
+	return "", false
+}
+
+// electronGenericNames are the path elements of an electron app that do not tell the app name.
+var electronGenericNames = []string{"app", "app.asar", "resources", "dist", "out", "main.js", "index.js", "cli.js"}
+
+// unwrapElectron returns the application directory name.
+func unwrapElectron(argv []string) (string, bool) {
+	for _, arg := range argv[min(1, len(argv)):] {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		path := filepath.Clean(arg)
+		for slices.Contains(electronGenericNames, filepath.Base(path)) {
+			path = filepath.Dir(path)
+		}
+		name := filepath.Base(path)
+		if name == "." || name == "/" {
+			return "", false
+		}
+		return strings.TrimSuffix(name, ".asar"), true
+	}
+	return "", false
+}
@@ -0,0 +1,86 @@
+package proc
+
+import (
+	"testing"
+)
+
+func TestChainResolver(t *testing.T) {
+	procPath := t.TempDir()
+	fakeProcess(t, procPath, 1, "java", "java", "-Xmx2g", "-cp", "lib/*", "com.example.Main")
+	fakeProcess(t, procPath, 2, "java", "/usr/bin/java", "-jar", "/opt/tools/jmc.jar")
+	fakeProcess(t, procPath, 3, "python3.12", "/usr/bin/python3", "-O", "/usr/bin/meld")
+	fakeProcess(t, procPath, 4, "python3.12", "python3", "-m", "http.server")
+	fakeProcess(t, procPath, 5, "python3.12", "python3", "-c", "print(1)")
+	fakeProcess(t, procPath, 6, "node", "node", "--inspect", "server.js")
+	fakeProcess(t, procPath, 7, "wine64-preloader", "C:\\Program Files\\Notepad++\\notepad++.exe")
+	fakeProcess(t, procPath, 8, "electron29", "/usr/lib/electron29/electron", "/usr/lib/signal-desktop/resources/app.asar")
+	fakeProcess(t, procPath, 9, "firefox", "/usr/lib/firefox/firefox")
+	fakeProcess(t, procPath, 10, "python3.12", "python3", "-W", "ignore", "/usr/bin/app.py")
+	fakeProcess(t, procPath, 11, "node", "node", "--require", "dotenv/config", "server.js")
+	fakeProcess(t, procPath, 12, "ruby", "ruby", "-I", "lib", "bin/rails")
+	fakeProcess(t, procPath, 13, "python3.12", "python3", "-I", "/usr/bin/app.py")
+	fakeProcess(t, procPath, 14, "python3.12", "/usr/bin/python3.12", "-E", "/usr/bin/meld")
+	fakeProcess(t, procPath, 15, "ruby3.2", "ruby3.2", "-I", "/opt/app/lib", "-E", "UTF-8", "/opt/app/bin/server")
+	fakeProcess(t, procPath, 16, "nodejs", "nodejs", "-r", "dotenv/config", "app.js")
+
+	launchers := []Launcher{}
+	for _, config := range []struct {
+		unwrap string
+		exe    []string
+	}{
+		{"java", []string{"java"}},
+		{"script", []string{`python[0-9.]*`, `node(js)?`, `ruby[0-9.]*`}},
+		{"wine", []string{`wine(64)?(-preloader)?`}},
+		{"electron", []string{`electron[0-9]*`}},
+	} {
+		launcher, err := NewLauncher(config.unwrap, config.exe)
+		if err != nil {
+			t.Fatal(err)
+		}
+		launchers = append(launchers, launcher)
+	}
+	resolver := NewChainResolver(&LinuxResolver{ProcPath: procPath}, launchers)
+
+	tests := []struct {
+		pid      uint32
+		expected string
+	}{
+		{1, "com.example.Main"},
+		{2, "jmc"},
+		{3, "meld"},
+		{4, "http.server"},
+		{5, "python3.12"},
+		{6, "server"},
+		{7, "notepad++.exe"},
+		{8, "signal-desktop"},
+		{9, "firefox"},
+		{10, "app"},
+		{11, "server"},
+		{12, "rails"},
+		{13, "app"},
+		{14, "meld"},
+		{15, "server"},
+		{16, "app"},
+	}
+	for _, test := range tests {
+		name, err := resolver.Resolve(test.pid)
+		if err != nil || name != test.expected {
+			t.Errorf("Expected %s, got %s (%v)", test.expected, name, err)
+		}
+	}
+
+	// Other process properties are resolved by the embedded resolver.
+	cmdline, err := resolver.ResolveCmdline(2)
+	if err != nil || cmdline != "/usr/bin/java -jar /opt/tools/jmc.jar" {
+		t.Errorf("Expected java command line, got %s (%v)", cmdline, err)
+	}
+}
+
+func TestNewLauncher(t *testing.T) {
+	if _, err := NewLauncher("unknown", []string{"foo"}); err == nil {
+		t.Errorf("Expected error for unknown unwrapper")
+	}
+	if _, err := NewLauncher("java", []string{"(java"}); err == nil {
+		t.Errorf("Expected error for invalid pattern")
+	}
+}
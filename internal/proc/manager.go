@@ -5,6 +5,7 @@ package proc
 
 import (
 	"log/slog"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -12,6 +13,8 @@ import (
 const (
 	// Set the cache TTL to 15 minutes.
 	CacheTTL = 15 * time.Minute
+	// The foreground process changes often, yet there is no need to walk /proc on every title change.
+	ForegroundCacheTTL = 5 * time.Second
 )
 
 // NameResolver resolves the name of the process executable.
@@ -19,48 +22,142 @@ type NameResolver interface {
 	Resolve(pid uint32) (string, error)
 }
 
-// ProcessManager holds cache of filename by pid.
+// CmdlineResolver resolves the full command line of the process.
+// The process manager resolves command lines if its NameResolver implements this interface as well.
+type CmdlineResolver interface {
+	ResolveCmdline(pid uint32) (string, error)
+}
+
+// ForegroundResolver resolves the foreground descendant of the process.
+// The process manager resolves foreground processes if its NameResolver implements this interface as well.
+type ForegroundResolver interface {
+	ResolveForeground(pid uint32) (uint32, error)
+}
+
+// AppIDResolver resolves the application ID of the process, e.g. from its cgroup.
+// The process manager resolves application IDs if its NameResolver implements this interface as well.
+type AppIDResolver interface {
+	ResolveAppID(pid uint32) (string, error)
+}
+
+// property is a kind of the cached process property.
+type property string
+
+const (
+	propertyName       property = "name"
+	propertyCmdline    property = "cmdline"
+	propertyForeground property = "foreground"
+	propertyAppID      property = "app_id"
+)
+
+// cacheKey is the cache key of a process property.
+type cacheKey struct {
+	pid      uint32
+	property property
+}
+
+// ProcessManager holds cache of process properties by pid.
 type ProcessManager struct {
 	mu       sync.Mutex
-	cache    map[uint32]CacheItem
+	cache    map[cacheKey]CacheItem
 	resolver NameResolver
 }
 
 // NewProcessManager creates ProcessManager instance.
 func NewProcessManager(resolver NameResolver) *ProcessManager {
 	return &ProcessManager{
-		cache:    make(map[uint32]CacheItem),
+		cache:    make(map[cacheKey]CacheItem),
 		resolver: resolver,
 	}
 }
 
-// CacheItem is a struct that contains the process name and the best before time.
+// CacheItem is a struct that contains the process property value and the best before time.
 type CacheItem struct {
-	Name       string
+	Value      string
 	BestBefore time.Time
 }
 
+// SetResolver sets the name resolver and drops the cached properties.
+func (pm *ProcessManager) SetResolver(resolver NameResolver) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.resolver = resolver
+	pm.cache = make(map[cacheKey]CacheItem)
+}
+
 // GetProcessName gets process name by pid
 func (pm *ProcessManager) GetProcessName(pid *uint32) (string, bool) {
+	return pm.get(pid, propertyName, CacheTTL, pm.getResolver().Resolve)
+}
+
+// GetProcessCmdline gets the full process command line by pid
+func (pm *ProcessManager) GetProcessCmdline(pid *uint32) (string, bool) {
+	resolver, ok := pm.getResolver().(CmdlineResolver)
+	if !ok {
+		return "", false
+	}
+	return pm.get(pid, propertyCmdline, CacheTTL, resolver.ResolveCmdline)
+}
+
+// GetProcessAppID gets the application ID of the process by pid
+func (pm *ProcessManager) GetProcessAppID(pid *uint32) (string, bool) {
+	resolver, ok := pm.getResolver().(AppIDResolver)
+	if !ok {
+		return "", false
+	}
+	return pm.get(pid, propertyAppID, CacheTTL, resolver.ResolveAppID)
+}
+
+// GetForegroundProcess gets pid of the foreground descendant of the process by pid
+func (pm *ProcessManager) GetForegroundProcess(pid *uint32) (*uint32, bool) {
+	resolver, ok := pm.getResolver().(ForegroundResolver)
+	if !ok {
+		return nil, false
+	}
+	value, ok := pm.get(pid, propertyForeground, ForegroundCacheTTL, func(pid uint32) (string, error) {
+		foreground, err := resolver.ResolveForeground(pid)
+		return strconv.FormatUint(uint64(foreground), 10), err
+	})
+	if !ok {
+		return nil, false
+	}
+	foreground, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return nil, false
+	}
+	result := uint32(foreground)
+	return &result, true
+}
+
+// getResolver returns the current name resolver.
+func (pm *ProcessManager) getResolver() NameResolver {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	return pm.resolver
+}
+
+// get gets the process property from the cache or resolves it.
+func (pm *ProcessManager) get(pid *uint32, property property, ttl time.Duration, resolve func(uint32) (string, error)) (string, bool) {
 	if pid == nil {
 		return "", false
 	}
+	key := cacheKey{pid: *pid, property: property}
 	pm.mu.Lock()
-	if item, ok := pm.cache[*pid]; ok && item.BestBefore.After(time.Now()) {
+	if item, ok := pm.cache[key]; ok && item.BestBefore.After(time.Now()) {
 		pm.mu.Unlock()
-		return item.Name, true
+		return item.Value, true
 	}
 	pm.mu.Unlock()
 
-	name, err := pm.resolver.Resolve(*pid)
+	value, err := resolve(*pid)
 	if err != nil {
-		slog.Error("error while getting executable name", "error", err)
+		slog.Error("error while getting process property", "pid", *pid, "property", property, "error", err)
 		return "", false
 	}
 
 	pm.mu.Lock()
-	pm.cache[*pid] = CacheItem{Name: name, BestBefore: time.Now().Add(CacheTTL)}
+	pm.cache[key] = CacheItem{Value: value, BestBefore: time.Now().Add(ttl)}
 	pm.mu.Unlock()
 
-	return name, true
+	return value, true
 }
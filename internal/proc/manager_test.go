@@ -7,13 +7,24 @@ import (
 
 type MockResolver struct {
 	Response string
+	Cmdline  string
 	Err      error
+	Calls    int
 }
 
 func (m *MockResolver) Resolve(pid uint32) (string, error) {
 	return m.Response, m.Err
 }
 
+func (m *MockResolver) ResolveCmdline(pid uint32) (string, error) {
+	m.Calls++
+	return m.Cmdline, m.Err
+}
+
+func (m *MockResolver) ResolveForeground(pid uint32) (uint32, error) {
+	return pid + 1, m.Err
+}
+
 func TestGetProcessNameSuccess(t *testing.T) {
 	mock := &MockResolver{Response: "my-app"}
 	mgr := NewProcessManager(mock)
@@ -37,3 +48,29 @@ func TestGetProcessNameError(t *testing.T) {
 		t.Errorf("Expected empty app name and false, got %t", ok)
 	}
 }
+
+func TestGetProcessCmdlineCached(t *testing.T) {
+	mock := &MockResolver{Response: "python3", Cmdline: "python3 /usr/bin/meld"}
+	mgr := NewProcessManager(mock)
+
+	pid := uint32(1234)
+	for range 3 {
+		cmdline, ok := mgr.GetProcessCmdline(&pid)
+		if !ok || cmdline != "python3 /usr/bin/meld" {
+			t.Errorf("Expected python3 /usr/bin/meld, got %s", cmdline)
+		}
+	}
+	if mock.Calls != 1 {
+		t.Errorf("Expected the command line to be resolved once, got %d", mock.Calls)
+	}
+}
+
+func TestGetForegroundProcess(t *testing.T) {
+	mgr := NewProcessManager(&MockResolver{})
+
+	pid := uint32(1234)
+	foreground, ok := mgr.GetForegroundProcess(&pid)
+	if !ok || *foreground != 1235 {
+		t.Errorf("Expected 1235, got %v", foreground)
+	}
+}
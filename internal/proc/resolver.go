@@ -1,8 +1,11 @@
 package proc
 
 import (
+	"bytes"
+	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 )
 
 // LinuxResolver is a struct that resolves the process name by pid.
@@ -12,10 +15,38 @@ type LinuxResolver struct {
 
 // Resolve resolves the process name by pid.
 func (l *LinuxResolver) Resolve(pid uint32) (string, error) {
-	exePath := filepath.Join(l.ProcPath, strconv.FormatUint(uint64(pid), 10), "exe")
+	exePath := filepath.Join(l.pidPath(pid), "exe")
 	realPath, err := filepath.EvalSymlinks(exePath)
 	if err != nil {
 		return "", err
 	}
 	return filepath.Base(realPath), nil
 }
+
+// ResolveCmdline resolves the process command line by pid.
+// The arguments are joined with spaces.
+func (l *LinuxResolver) ResolveCmdline(pid uint32) (string, error) {
+	argv, err := l.argv(pid)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(argv, " "), nil
+}
+
+// argv reads the process arguments from /proc/<pid>/cmdline.
+func (l *LinuxResolver) argv(pid uint32) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(l.pidPath(pid), "cmdline"))
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimRight(data, "\x00")
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return strings.Split(string(data), "\x00"), nil
+}
+
+// pidPath returns the /proc directory of the process.
+func (l *LinuxResolver) pidPath(pid uint32) string {
+	return filepath.Join(l.ProcPath, strconv.FormatUint(uint64(pid), 10))
+}
@@ -0,0 +1,64 @@
+package proc
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// fakeProcess creates /proc/<pid> entries for the process in the fake proc directory.
+func fakeProcess(t *testing.T, procPath string, pid uint32, exe string, argv ...string) {
+	t.Helper()
+	pidPath := filepath.Join(procPath, strconv.FormatUint(uint64(pid), 10))
+	if err := os.MkdirAll(pidPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	exePath := filepath.Join(procPath, "bin", exe)
+	if err := os.MkdirAll(filepath.Dir(exePath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(exePath, nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(exePath, filepath.Join(pidPath, "exe")); err != nil {
+		t.Fatal(err)
+	}
+	cmdline := ""
+	for _, arg := range argv {
+		cmdline += arg + "\x00"
+	}
+	if err := os.WriteFile(filepath.Join(pidPath, "cmdline"), []byte(cmdline), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLinuxResolver(t *testing.T) {
+	procPath := t.TempDir()
+	fakeProcess(t, procPath, 100, "python3.12", "/usr/bin/python3", "/usr/bin/meld", "--newtab")
+	fakeProcess(t, procPath, 200, "kworker")
+	resolver := &LinuxResolver{ProcPath: procPath}
+
+	tests := []struct {
+		pid     uint32
+		name    string
+		cmdline string
+	}{
+		{100, "python3.12", "/usr/bin/python3 /usr/bin/meld --newtab"},
+		{200, "kworker", ""},
+	}
+	for _, test := range tests {
+		name, err := resolver.Resolve(test.pid)
+		if err != nil || name != test.name {
+			t.Errorf("Expected %s, got %s (%v)", test.name, name, err)
+		}
+		cmdline, err := resolver.ResolveCmdline(test.pid)
+		if err != nil || cmdline != test.cmdline {
+			t.Errorf("Expected %q, got %q (%v)", test.cmdline, cmdline, err)
+		}
+	}
+
+	if _, err := resolver.ResolveCmdline(300); err == nil {
+		t.Errorf("Expected error for missing process")
+	}
+}
@@ -0,0 +1,140 @@
+package proc
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxTreeDepth limits the process tree descent as the tree may change while it is read.
+const maxTreeDepth = 16
+
+// ResolveForeground resolves the foreground process running under the process by pid,
+// e.g. the editor running in a terminal emulator.
+// It follows the terminal foreground process group (tpgid) of the newest terminal child with one.
+func (l *LinuxResolver) ResolveForeground(pid uint32) (uint32, error) {
+	children, err := l.children(pid)
+	if err != nil {
+		return 0, err
+	}
+	var shells []uint32
+	for _, child := range children {
+		if stat, ok := l.stat(child); ok && stat.tpgid > 0 {
+			shells = append(shells, child)
+		}
+	}
+	if len(shells) == 0 {
+		return pid, nil
+	}
+	shell := l.newest(shells)
+	stat, ok := l.stat(shell)
+	if !ok {
+		// The shell has just gone, the terminal is still good enough.
+		return pid, nil
+	}
+	return l.groupProcess(shell, stat.tpgid), nil
+}
+
+// groupProcess returns the leader of the process group or its first member found under the shell
+// when the leader is gone, the shell itself when there is none.
+func (l *LinuxResolver) groupProcess(shell uint32, pgid int64) uint32 {
+	if stat, ok := l.stat(uint32(pgid)); ok && stat.pgrp == pgid {
+		return uint32(pgid)
+	}
+	level := []uint32{shell}
+	for depth := 0; depth < maxTreeDepth && len(level) > 0; depth++ {
+		var next []uint32
+		for _, pid := range level {
+			children, _ := l.children(pid)
+			for _, child := range children {
+				if stat, ok := l.stat(child); ok && stat.pgrp == pgid {
+					return child
+				}
+			}
+			next = append(next, children...)
+		}
+		level = next
+	}
+	return shell
+}
+
+// children reads the children of all the process threads from /proc/<pid>/task/*/children.
+func (l *LinuxResolver) children(pid uint32) ([]uint32, error) {
+	taskPath := filepath.Join(l.pidPath(pid), "task")
+	tasks, err := os.ReadDir(taskPath)
+	if err != nil {
+		return nil, err
+	}
+	var children []uint32
+	for _, task := range tasks {
+		data, err := os.ReadFile(filepath.Join(taskPath, task.Name(), "children"))
+		if err != nil {
+			continue
+		}
+		for _, field := range strings.Fields(string(data)) {
+			child, err := strconv.ParseUint(field, 10, 32)
+			if err != nil {
+				continue
+			}
+			children = append(children, uint32(child))
+		}
+	}
+	return children, nil
+}
+
+// newest returns the most recently started process, the highest pid wins when the start time is unknown.
+func (l *LinuxResolver) newest(pids []uint32) uint32 {
+	newest, newestStart := pids[0], l.startTime(pids[0])
+	for _, pid := range pids[1:] {
+		start := l.startTime(pid)
+		if start > newestStart || (start == newestStart && pid > newest) {
+			newest, newestStart = pid, start
+		}
+	}
+	return newest
+}
+
+// procStat are the process properties read from /proc/<pid>/stat.
+type procStat struct {
+	// pgrp is the process group ID.
+	pgrp int64
+	// tpgid is the foreground process group ID of the controlling terminal, -1 without a terminal.
+	tpgid     int64
+	startTime uint64
+}
+
+// stat reads the process properties from /proc/<pid>/stat.
+func (l *LinuxResolver) stat(pid uint32) (procStat, bool) {
+	data, err := os.ReadFile(filepath.Join(l.pidPath(pid), "stat"))
+	if err != nil {
+		return procStat{}, false
+	}
+	// The command name may contain spaces and parentheses, the fields follow the last ")".
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+	// The fields start with the field 3 (state): pgrp is the field 5, tpgid the field 8 and starttime the field 22.
+	const pgrpIndex, tpgidIndex, startTimeIndex = 5 - 3, 8 - 3, 22 - 3
+	if len(fields) <= startTimeIndex {
+		return procStat{}, false
+	}
+	pgrp, err := strconv.ParseInt(fields[pgrpIndex], 10, 64)
+	if err != nil {
+		return procStat{}, false
+	}
+	tpgid, err := strconv.ParseInt(fields[tpgidIndex], 10, 64)
+	if err != nil {
+		return procStat{}, false
+	}
+	start, err := strconv.ParseUint(fields[startTimeIndex], 10, 64)
+	if err != nil {
+		return procStat{}, false
+	}
+	return procStat{pgrp: pgrp, tpgid: tpgid, startTime: start}, true
+}
+
+// startTime returns the process start time, 0 if unknown.
+func (l *LinuxResolver) startTime(pid uint32) uint64 {
+	stat, _ := l.stat(pid)
+	return stat.startTime
+}
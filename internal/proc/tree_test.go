@@ -0,0 +1,99 @@
+package proc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeTask creates /proc/<pid>/task/<tid>/children listing the children.
+func fakeTask(t *testing.T, procPath string, pid uint32, tid uint32, children ...uint32) {
+	t.Helper()
+	taskPath := filepath.Join(procPath, strconv.FormatUint(uint64(pid), 10), "task", strconv.FormatUint(uint64(tid), 10))
+	if err := os.MkdirAll(taskPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	fields := make([]string, 0, len(children))
+	for _, child := range children {
+		fields = append(fields, strconv.FormatUint(uint64(child), 10))
+	}
+	if err := os.WriteFile(filepath.Join(taskPath, "children"), []byte(strings.Join(fields, " ")+" "), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fakeStat creates /proc/<pid>/stat with the process group, the terminal foreground process group and the start time.
+func fakeStat(t *testing.T, procPath string, pid uint32, pgrp int64, tpgid int64, start uint64) {
+	t.Helper()
+	pidPath := filepath.Join(procPath, strconv.FormatUint(uint64(pid), 10))
+	if err := os.MkdirAll(pidPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// pid (comm) state ppid pgrp session tty_nr tpgid and 13 more fields before starttime
+	stat := fmt.Sprintf("%d (some (odd) name) S 1 %d %d 34816 %d%s %d 0 0\n", pid, pgrp, pgrp, tpgid, strings.Repeat(" 0", 13), start)
+	if err := os.WriteFile(filepath.Join(pidPath, "stat"), []byte(stat), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLinuxResolver_ResolveForeground(t *testing.T) {
+	procPath := t.TempDir()
+	// kitty (1) has two shells (10, 11) started by different threads, the newest one runs nvim (20)
+	fakeTask(t, procPath, 1, 1, 10)
+	fakeTask(t, procPath, 1, 2, 11)
+	fakeStat(t, procPath, 10, 10, 10, 100)
+	fakeTask(t, procPath, 10, 10)
+	fakeStat(t, procPath, 11, 11, 20, 200)
+	fakeTask(t, procPath, 11, 11, 20)
+	fakeStat(t, procPath, 20, 20, 20, 300)
+	fakeTask(t, procPath, 20, 20)
+	// idle terminal (2) runs a shell (30) with a background job (31) started later
+	fakeTask(t, procPath, 2, 2, 30)
+	fakeStat(t, procPath, 30, 30, 30, 100)
+	fakeTask(t, procPath, 30, 30, 31)
+	fakeStat(t, procPath, 31, 31, 30, 200)
+	// terminal (3) runs nvim (42) in the foreground and make (41) in the background started later
+	fakeTask(t, procPath, 3, 3, 40)
+	fakeStat(t, procPath, 40, 40, 42, 100)
+	fakeTask(t, procPath, 40, 40, 42, 41)
+	fakeStat(t, procPath, 41, 41, 42, 300)
+	fakeStat(t, procPath, 42, 42, 42, 200)
+	// terminal (4) runs a pipeline (52) whose leader (51) is gone
+	fakeTask(t, procPath, 4, 4, 50)
+	fakeStat(t, procPath, 50, 50, 51, 100)
+	fakeTask(t, procPath, 50, 50, 52)
+	fakeStat(t, procPath, 52, 51, 51, 200)
+	fakeTask(t, procPath, 52, 52)
+	// the terminal helper (61) without a controlling terminal is not a shell
+	fakeTask(t, procPath, 6, 6, 60, 61)
+	fakeStat(t, procPath, 60, 60, 60, 100)
+	fakeTask(t, procPath, 60, 60)
+	fakeStat(t, procPath, 61, 61, -1, 200)
+	resolver := &LinuxResolver{ProcPath: procPath}
+
+	tests := []struct {
+		name     string
+		pid      uint32
+		expected uint32
+	}{
+		{"newest shell", 1, 20},
+		{"background job", 2, 30},
+		{"foreground and background jobs", 3, 42},
+		{"group leader gone", 4, 52},
+		{"helper process", 6, 60},
+		{"no children", 20, 20},
+	}
+	for _, test := range tests {
+		foreground, err := resolver.ResolveForeground(test.pid)
+		if err != nil || foreground != test.expected {
+			t.Errorf("%s: expected %d, got %d (%v)", test.name, test.expected, foreground, err)
+		}
+	}
+
+	if _, err := resolver.ResolveForeground(5); err == nil {
+		t.Errorf("Expected error for missing process")
+	}
+}
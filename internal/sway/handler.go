@@ -17,6 +17,21 @@ var (
 		sc.WindowNew:   true,
 		sc.WindowTitle: true,
 		sc.WindowClose: true,
+		sc.WindowFocus: true,
+		// The urgency hint is both set and cleared with this event
+		sc.WindowUrgent: true,
+		// Window states shown by the decorations
+		sc.WindowFullscreen: true,
+		sc.WindowFloating:   true,
+	}
+
+	// workspaceChangeTypes is a map of workspace event changes that we are interested in.
+	// The renames are left out as they are caused by us.
+	workspaceChangeTypes = map[sc.WorkspaceEventChange]bool{
+		// The focused workspace shows the scratchpad badge
+		sc.WorkspaceFocus: true,
+		// The output icon follows the workspace to another output
+		sc.WorkspaceMove: true,
 	}
 )
 
@@ -43,9 +58,18 @@ func NewHandler(nameFormatter workspace.NameFormatter, iconProvider *display.Ico
 func (h *handler) ReloadConfig(newConfig *config.Config) error {
 	slog.Info("Reloading configuration...")
 
+	matcher, err := display.NewMatcher(newConfig)
+	if err != nil {
+		return err
+	}
+	nameFormatter, err := display.NewOutputNameFormatter(newConfig)
+	if err != nil {
+		return err
+	}
+
 	h.config = newConfig
-	h.nameFormatter = display.NewNameFormatter(h.config.Format)
-	h.iconProvider.SetIconMap(display.AppToIconMap(newConfig.AppToIcon))
+	h.nameFormatter = nameFormatter
+	h.iconProvider.SetMatcher(matcher)
 	h.iconProvider.ClearCache()
 	slog.Info("Configuration reloaded successfully")
 	return nil
@@ -61,16 +85,31 @@ func (h handler) Window(ctx context.Context, event sc.WindowEvent) {
 	}
 }
 
+// Workspace event handler
+func (h handler) Workspace(ctx context.Context, event sc.WorkspaceEvent) {
+	if _, ok := workspaceChangeTypes[event.Change]; !ok {
+		return
+	}
+	if err := h.processWorkspaces(ctx); err != nil {
+		slog.Error("Error while processing the event", "error", err)
+	}
+}
+
 // processWorkspaces processes the workspaces and renames them according to the name formatter and icon provider
 // basing on the apps running on the workspaces.
 func (h *handler) processWorkspaces(ctx context.Context) error {
-	sway, err := NewSwayClient(ctx)
+	// The output properties are only matched by the output icons
+	sway, err := NewSwayClient(ctx, len(h.config.OutputIcons) > 0)
 	if err != nil {
 		return err
 	}
 
-	// Then traverse the tree and populate the workspaces map.
-	workspaces, err := sway.CollectWorkspaces()
+	// Then traverse the tree and populate the workspaces map, the optional parts only when the format shows them.
+	workspaces, err := sway.CollectWorkspaces(CollectOptions{
+		Scratchpad: h.config.ScratchpadBadge(),
+		Floating:   h.config.Floating(),
+		Layout:     h.config.Layout(),
+	})
 	if err != nil {
 		return err
 	}
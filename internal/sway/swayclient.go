@@ -1,8 +1,10 @@
 package sway
 
 import (
+	"cmp"
 	"context"
 	"log/slog"
+	"slices"
 	"sway-icon-to-go/internal/workspace"
 
 	sc "github.com/joshuarubin/go-sway"
@@ -12,19 +14,35 @@ const (
 	// Scratchpad workspace name is "__i3_scratch".
 	// See https://pkg.go.dev/github.com/joshuarubin/go-sway@v1.2.0#Node for more details.
 	ScratchpadWorkspaceName = "__i3_scratch"
+	// ScratchpadWorkspaceNumber is the number of the scratchpad, sway numbers the named workspaces -1 as well.
+	ScratchpadWorkspaceNumber = -1
 )
 
 // SwayClient is an interface that provides a way to interact with the Sway window manager.
 type SwayClient interface {
-	CollectWorkspaces() (workspace.Workspaces, error)
+	CollectWorkspaces(options CollectOptions) (workspace.Workspaces, error)
 	RenameWorkspaces(workspaces workspace.Workspaces, nameFormatter workspace.NameFormatter) error
 }
 
-// WorkspaceNumByName is a map of workspace name to workspace number.
-type WorkspaceNumByName map[string]int64
+// CollectOptions tells which optional parts of the tree are collected.
+type CollectOptions struct {
+	// Scratchpad collects the scratchpad windows shared by the workspaces.
+	Scratchpad bool
+	// Floating collects the floating windows of the workspaces.
+	Floating bool
+	// Layout builds the container tree of the workspaces.
+	Layout bool
+}
+
+// WorkspaceByName is a map of workspace name to the sway workspace.
+type WorkspaceByName map[string]sc.Workspace
+
+// OutputByName is a map of output name to the sway output.
+type OutputByName map[string]sc.Output
 
 // NewSwayClient creates a new SwayClient instance.
-func NewSwayClient(ctx context.Context) (SwayClient, error) {
+// The outputs are only fetched when asked for, the workspaces know the output names anyway.
+func NewSwayClient(ctx context.Context, outputs bool) (SwayClient, error) {
 	client, err := sc.New(ctx)
 	if err != nil {
 		return nil, err
@@ -35,14 +53,27 @@ func NewSwayClient(ctx context.Context) (SwayClient, error) {
 		client: client,
 	}
 
-	// First pre-populate the workspace number by name
-	s.workspaceNumByName = make(WorkspaceNumByName)
+	// First pre-populate the workspaces by name
+	s.workspaceByName = make(WorkspaceByName)
 	swayWorkspaces, err := s.client.GetWorkspaces(s.ctx)
 	if err != nil {
 		return nil, err
 	}
 	for _, ws := range swayWorkspaces {
-		s.workspaceNumByName[ws.Name] = ws.Num
+		s.workspaceByName[ws.Name] = ws
+	}
+
+	// The workspaces only have the output name, the output icons match the other properties too
+	s.outputByName = make(OutputByName)
+	if !outputs {
+		return s, nil
+	}
+	swayOutputs, err := s.client.GetOutputs(s.ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, output := range swayOutputs {
+		s.outputByName[output.Name] = output
 	}
 
 	return s, nil
@@ -52,26 +83,36 @@ func NewSwayClient(ctx context.Context) (SwayClient, error) {
 type swayClient struct {
 	ctx    context.Context
 	client sc.Client
-	// workspaceNumByName is a map of workspace name to workspace number
-	// Sadly Node with type NodeWorkspace does not have
-	// this property, so we need to get it from the sway workspaces
-	workspaceNumByName WorkspaceNumByName
+	// workspaceByName is a map of workspace name to the sway workspace
+	// Sadly Node with type NodeWorkspace does not have the number,
+	// focus and output properties, so we need to get them from the sway workspaces
+	workspaceByName WorkspaceByName
+	outputByName    OutputByName
 }
 
 // Subscribe subscribes to the Sway window manager events.
 func Subscribe(ctx context.Context, handler sc.EventHandler) error {
-	return sc.Subscribe(ctx, handler, sc.EventTypeWindow)
+	return sc.Subscribe(ctx, handler, sc.EventTypeWindow, sc.EventTypeWorkspace)
 }
 
 // CollectWorkspaces collects the workspaces from the Sway window manager.
-func (s *swayClient) CollectWorkspaces() (workspace.Workspaces, error) {
+// When asked for, the scratchpad windows are collected apart and shared by all the workspaces.
+func (s *swayClient) CollectWorkspaces(options CollectOptions) (workspace.Workspaces, error) {
 	workspaces := make(workspace.Workspaces, 0)
 	tree, err := s.client.GetTree(s.ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	s.traverseTree(tree, workspaces)
+	if !options.Scratchpad {
+		s.traverseTree(tree, workspaces, nil, options)
+		return workspaces, nil
+	}
+	scratchpad := workspace.NewWorkspace(ScratchpadWorkspaceName, ScratchpadWorkspaceNumber)
+	s.traverseTree(tree, workspaces, scratchpad, options)
+	for _, ws := range workspaces {
+		ws.Scratchpad = scratchpad
+	}
 	return workspaces, nil
 }
 
@@ -90,51 +131,142 @@ func (s *swayClient) RenameWorkspaces(workspaces workspace.Workspaces, nameForma
 	return nil
 }
 
-// traverseTree traverses the tree and populates the initial workspaces map.
-func (s *swayClient) traverseTree(node *sc.Node, workspaces workspace.Workspaces) {
+// traverseTree traverses the tree and populates the initial workspaces map and the scratchpad.
+// The scratchpad windows are skipped when the scratchpad is nil.
+func (s *swayClient) traverseTree(node *sc.Node, workspaces workspace.Workspaces, scratchpad *workspace.Workspace, options CollectOptions) {
 	switch node.Type {
 	case sc.NodeWorkspace:
 		if node.Name == ScratchpadWorkspaceName {
-			slog.Debug("Ignoring scratchpad workspace", "name", node.Name)
+			if scratchpad != nil {
+				s.traverseWorkspace(node, scratchpad)
+			}
 			return
 		}
 
-		workspaceNum, ok := s.workspaceNumByName[node.Name]
+		swayWorkspace, ok := s.workspaceByName[node.Name]
 		if !ok {
-			// Workspace not found in workspaceNumByName, so we skip it
-			slog.Warn("Workspace not found in workspaceNumByName", "name", node.Name)
+			// Workspace not found in workspaceByName, so we skip it
+			slog.Warn("Workspace not found in workspaceByName", "name", node.Name)
 			return
 		}
 
-		ws := workspace.NewWorkspace(node.Name, workspaceNum)
+		ws := workspace.NewWorkspace(node.Name, swayWorkspace.Num)
+		ws.Focused = swayWorkspace.Focused
+		output := s.outputByName[swayWorkspace.Output]
+		ws.Output = workspace.OutputInfo{
+			Name:   swayWorkspace.Output,
+			Make:   output.Make,
+			Model:  output.Model,
+			Serial: output.Serial,
+		}
+		if options.Layout {
+			ws.Layout = newContainer(node)
+		}
 		workspaces[ws.Number] = ws
 		for _, child := range node.Nodes {
-			s.traverseWorkspace(child, ws.Number, workspaces)
+			s.traverseWorkspace(child, ws)
+		}
+		if options.Floating {
+			for _, child := range node.FloatingNodes {
+				s.traverseWorkspace(child, ws)
+			}
+		}
+		ranks := focusRanks(node)
+		for i := range ws.Windows {
+			ws.Windows[i].FocusRank = ranks[ws.Windows[i].ID]
 		}
 	default:
 		for _, child := range node.Nodes {
-			s.traverseTree(child, workspaces)
+			s.traverseTree(child, workspaces, scratchpad, options)
 		}
 	}
 }
 
-// traverseWorkspace traverses the workspace and populates the workspaces map.
-func (s *swayClient) traverseWorkspace(node *sc.Node, workspaceNumber int64, workspaces workspace.Workspaces) {
+// traverseWorkspace traverses the workspace and adds its windows.
+func (s *swayClient) traverseWorkspace(node *sc.Node, ws *workspace.Workspace) {
 	if node.Type == sc.NodeCon || node.Type == sc.NodeFloatingCon {
 		// Ignore ghost nodes that we can't resolve anyway
 		if !(node.PID == nil && node.Name == "") {
-			windowInfo := workspace.WindowInfo{
-				PID:   node.PID,
-				Title: node.Name,
-			}
-			workspaces[workspaceNumber].AddWindow(windowInfo)
+			ws.AddWindow(newWindowInfo(node))
 		}
 	}
 	for _, child := range node.Nodes {
-		s.traverseWorkspace(child, workspaceNumber, workspaces)
+		s.traverseWorkspace(child, ws)
 	}
 
 	for _, child := range node.FloatingNodes {
-		s.traverseWorkspace(child, workspaceNumber, workspaces)
+		s.traverseWorkspace(child, ws)
+	}
+}
+
+// newContainer builds the layout tree of the tiled children of the node, the floating windows are left out.
+func newContainer(node *sc.Node) *workspace.Container {
+	container := &workspace.Container{ID: node.ID, Layout: string(node.Layout)}
+	for _, child := range node.Nodes {
+		container.Children = append(container.Children, newContainer(child))
+	}
+	return container
+}
+
+// newWindowInfo extracts the window properties we match icons against from the node.
+func newWindowInfo(node *sc.Node) workspace.WindowInfo {
+	windowInfo := workspace.WindowInfo{
+		ID:             node.ID,
+		PID:            node.PID,
+		Title:          node.Name,
+		Floating:       node.Type == sc.NodeFloatingCon,
+		FullscreenMode: int(node.FullscreenMode),
+		Sticky:         node.Sticky,
+		Focused:        node.Focused,
+		Rect: workspace.Rect{
+			X:      node.Rect.X,
+			Y:      node.Rect.Y,
+			Width:  node.Rect.Width,
+			Height: node.Rect.Height,
+		},
+	}
+	if node.AppID != nil {
+		windowInfo.AppID = *node.AppID
+	}
+	if node.Urgent != nil {
+		windowInfo.Urgent = *node.Urgent
+	}
+	if node.Shell != nil {
+		windowInfo.Shell = *node.Shell
+	}
+	if node.WindowProperties != nil {
+		windowInfo.Class = node.WindowProperties.Class
+		windowInfo.Instance = node.WindowProperties.Instance
+	}
+	return windowInfo
+}
+
+// focusRanks ranks the windows under the node by the focus history, the most recently focused first.
+// Every node lists its children IDs in the focus order, so following it depth first gives the window order.
+func focusRanks(node *sc.Node) map[int64]int {
+	ranks := make(map[int64]int)
+	var walk func(node *sc.Node)
+	walk = func(node *sc.Node) {
+		children := append(slices.Clone(node.Nodes), node.FloatingNodes...)
+		if len(children) == 0 {
+			ranks[node.ID] = len(ranks)
+			return
+		}
+		slices.SortStableFunc(children, func(a, b *sc.Node) int {
+			return cmp.Compare(focusIndex(node.Focus, a.ID), focusIndex(node.Focus, b.ID))
+		})
+		for _, child := range children {
+			walk(child)
+		}
+	}
+	walk(node)
+	return ranks
+}
+
+// focusIndex returns the position of the ID in the focus order, the IDs missing from it go last.
+func focusIndex(focus []int64, id int64) int {
+	if i := slices.Index(focus, id); i >= 0 {
+		return i
 	}
+	return len(focus)
 }
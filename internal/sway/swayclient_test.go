@@ -0,0 +1,106 @@
+package sway
+
+import (
+	"sway-icon-to-go/internal/config"
+	"sway-icon-to-go/internal/display"
+	"sway-icon-to-go/internal/workspace"
+	"testing"
+
+	sc "github.com/joshuarubin/go-sway"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// window creates a window node.
+func window(id int64, nodeType sc.NodeType, appID string) *sc.Node {
+	pid := uint32(id)
+	return &sc.Node{ID: id, Type: nodeType, Name: appID, AppID: &appID, PID: &pid}
+}
+
+func TestSwayClient_TraverseTree_Floating(t *testing.T) {
+	tree := &sc.Node{ID: 1, Type: sc.NodeRoot, Nodes: []*sc.Node{
+		{ID: 2, Type: sc.NodeOutput, Nodes: []*sc.Node{
+			{
+				ID:            3,
+				Type:          sc.NodeWorkspace,
+				Name:          "1",
+				Layout:        sc.LayoutSplitH,
+				Nodes:         []*sc.Node{window(10, sc.NodeCon, "firefox"), window(11, sc.NodeCon, "foot")},
+				FloatingNodes: []*sc.Node{window(12, sc.NodeFloatingCon, "qalculate")},
+			},
+		}},
+	}}
+	s := &swayClient{workspaceByName: WorkspaceByName{"1": {Num: 1, Name: "1", Output: "eDP-1"}}}
+
+	workspaces := make(workspace.Workspaces)
+	s.traverseTree(tree, workspaces, nil, CollectOptions{Floating: true})
+	require.Contains(t, workspaces, int64(1))
+	assert.Nil(t, workspaces[1].Layout, "the layout is only built when asked for")
+	assert.Equal(t, workspace.OutputInfo{Name: "eDP-1"}, workspaces[1].Output, "the output name is known without the outputs")
+	windows := workspaces[1].Windows
+	require.Len(t, windows, 3)
+	assert.Equal(t, "qalculate", windows[2].AppID)
+	assert.True(t, windows[2].Floating)
+	assert.False(t, windows[0].Floating)
+
+	// The default format neither decorates nor groups the floating windows, the names stay as they were
+	cfg := &config.Config{Format: config.DefaultFormat()}
+	require.False(t, cfg.Floating())
+	workspaces = make(workspace.Workspaces)
+	s.traverseTree(tree, workspaces, nil, CollectOptions{Floating: cfg.Floating()})
+	for _, w := range workspaces[1].Windows {
+		workspaces[1].AddAppIcon(workspace.AppIcon{Icon: w.AppID, Window: w})
+	}
+	nameFormatter, err := display.NewOutputNameFormatter(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "1: firefox|foot", nameFormatter.Format(workspaces[1]))
+}
+
+func TestSwayClient_TraverseTree_Scratchpad(t *testing.T) {
+	tree := &sc.Node{ID: 1, Type: sc.NodeRoot, Nodes: []*sc.Node{
+		{ID: 2, Type: sc.NodeOutput, Name: "__i3", Nodes: []*sc.Node{
+			{
+				ID:            3,
+				Type:          sc.NodeWorkspace,
+				Name:          ScratchpadWorkspaceName,
+				FloatingNodes: []*sc.Node{window(10, sc.NodeFloatingCon, "keepassxc")},
+			},
+		}},
+	}}
+	s := &swayClient{workspaceByName: WorkspaceByName{}}
+
+	scratchpad := workspace.NewWorkspace(ScratchpadWorkspaceName, ScratchpadWorkspaceNumber)
+	s.traverseTree(tree, make(workspace.Workspaces), scratchpad, CollectOptions{})
+	require.Len(t, scratchpad.Windows, 1)
+	assert.Equal(t, "keepassxc", scratchpad.Windows[0].AppID)
+
+	workspaces := make(workspace.Workspaces)
+	s.traverseTree(tree, workspaces, nil, CollectOptions{})
+	assert.Empty(t, workspaces, "the scratchpad is skipped without a badge")
+}
+
+func TestSwayClient_TraverseTree_Layout(t *testing.T) {
+	tree := &sc.Node{ID: 1, Type: sc.NodeRoot, Nodes: []*sc.Node{
+		{ID: 2, Type: sc.NodeOutput, Nodes: []*sc.Node{
+			{
+				ID:     3,
+				Type:   sc.NodeWorkspace,
+				Name:   "1",
+				Layout: sc.LayoutSplitH,
+				Nodes: []*sc.Node{
+					window(10, sc.NodeCon, "firefox"),
+					{ID: 4, Type: sc.NodeCon, Layout: sc.LayoutTabbed, Nodes: []*sc.Node{window(11, sc.NodeCon, "foot")}},
+				},
+			},
+		}},
+	}}
+	s := &swayClient{workspaceByName: WorkspaceByName{"1": {Num: 1, Name: "1"}}}
+	workspaces := make(workspace.Workspaces)
+	s.traverseTree(tree, workspaces, nil, CollectOptions{Layout: true})
+
+	require.Contains(t, workspaces, int64(1))
+	assert.Equal(t, &workspace.Container{ID: 3, Layout: "splith", Children: []*workspace.Container{
+		{ID: 10},
+		{ID: 4, Layout: "tabbed", Children: []*workspace.Container{{ID: 11}}},
+	}}, workspaces[1].Layout)
+}
@@ -10,13 +10,62 @@ import (
 
 // NameFormatter is an interface that formats a workspace name.
 type NameFormatter interface {
-	Format(workspaceNumber int64, appIcons []string) string
+	Format(w *Workspace) string
 }
 
-// WindowInfo is a struct that represents a window with its PID and title.
+// AppIcon is the icon of a window with the optional label.
+type AppIcon struct {
+	Icon string
+	// Label is the label template, e.g. "{icon} {file}", the icon is shown alone when empty.
+	Label string
+	// Captures are the named captures of the matched rule pattern the label is rendered with.
+	Captures map[string]string
+	// Window is the window the icon stands for.
+	Window WindowInfo
+	Style  Style
+}
+
+// Style is the Pango span style of an app icon, the empty attributes are not set.
+type Style struct {
+	Color string
+	Font  string
+	Size  string
+	Rise  string
+}
+
+// Rect is the absolute geometry of a window.
+type Rect struct {
+	X      int64
+	Y      int64
+	Width  int64
+	Height int64
+}
+
+// WindowInfo is a struct that represents a window with its identifying properties.
 type WindowInfo struct {
+	// ID is the sway node ID.
+	ID    int64
 	PID   *uint32
 	Title string
+	// AppID is the Wayland app_id, set for xdg-shell windows only.
+	AppID string
+	// Class and Instance come from the X11 WM_CLASS, set for xwayland windows only.
+	Class    string
+	Instance string
+	// Shell is the shell of the window such as "xdg_shell" or "xwayland".
+	Shell string
+	// Floating is set for the floating containers, tiled ones are not.
+	Floating bool
+	// FullscreenMode is 0 for none, 1 for the output and 2 for the global fullscreen.
+	FullscreenMode int
+	// Sticky is set for the windows shown on all the workspaces of the output.
+	Sticky  bool
+	Focused bool
+	// Urgent is set while the window has the urgency hint.
+	Urgent bool
+	Rect   Rect
+	// FocusRank is the position of the window in the workspace focus history, 0 is the most recently focused.
+	FocusRank int
 }
 
 // Workspace is a struct that represents a workspace.
@@ -24,7 +73,35 @@ type Workspace struct {
 	Name     string
 	Number   int64
 	Windows  []WindowInfo
-	AppIcons []string
+	AppIcons []AppIcon
+	Focused  bool
+	// Output is the output the workspace is on.
+	Output OutputInfo
+	// OutputIcon is the icon of the output the workspace is on, empty when no output icon matches.
+	OutputIcon string
+	// Layout is the container tree of the workspace, the root is the workspace itself.
+	Layout *Container
+	// Scratchpad holds the scratchpad windows shared by all the workspaces, nil when not collected.
+	Scratchpad *Workspace
+}
+
+// OutputInfo holds the output properties we match output icons against.
+// Only the name is known unless the output icons are configured.
+type OutputInfo struct {
+	// Name is the output name, e.g. "eDP-1".
+	Name   string
+	Make   string
+	Model  string
+	Serial string
+}
+
+// Container is a node of the workspace layout tree, the windows are the leaves.
+type Container struct {
+	// ID is the sway node ID, a leaf is the window with the same ID.
+	ID int64
+	// Layout is the sway layout of the children: splith, splitv, tabbed or stacked.
+	Layout   string
+	Children []*Container
 }
 
 // NewWorkspace creates a new workspace.
@@ -33,7 +110,7 @@ func NewWorkspace(name string, number int64) *Workspace {
 		Name:     name,
 		Number:   number,
 		Windows:  make([]WindowInfo, 0, 10),
-		AppIcons: make([]string, 0, 10),
+		AppIcons: make([]AppIcon, 0, 10),
 	}
 }
 
@@ -43,7 +120,7 @@ func (w *Workspace) String() string {
 }
 
 // AddAppIcon adds an app icon to the workspace.
-func (w *Workspace) AddAppIcon(appIcon string) {
+func (w *Workspace) AddAppIcon(appIcon AppIcon) {
 	w.AppIcons = append(w.AppIcons, appIcon)
 }
 
@@ -54,7 +131,7 @@ func (w *Workspace) AddWindow(window WindowInfo) {
 
 // ToRenameCommand produces Sway rename command for the workspace.
 func (w *Workspace) ToRenameCommand(nf NameFormatter) string {
-	newName := nf.Format(w.Number, w.AppIcons)
+	newName := nf.Format(w)
 	// Do not rename if nothing has been changed
 	if newName == w.Name {
 		return ""
@@ -68,11 +145,12 @@ func (w *Workspace) ToRenameCommand(nf NameFormatter) string {
 }
 
 // sanitizeName escapes the name for the sway command.
+// The name is double quoted in the command, so the semicolons of the Pango markup entities are kept
+// and the double quotes and backslashes are escaped, sway unescapes them back.
 func sanitizeName(name string) string {
 	cleaned := strings.NewReplacer(
-		`\`, "",
-		`"`, "",
-		`;`, "",
+		`\`, `\\`,
+		`"`, `\"`,
 		"\n", "",
 		"\r", "",
 	).Replace(name)